@@ -0,0 +1,107 @@
+package client
+
+import (
+	"encoding/json"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/backoff"
+	"google.golang.org/grpc/balancer/leastrequest"
+	"google.golang.org/grpc/balancer/pickfirst"
+	"google.golang.org/grpc/balancer/roundrobin"
+	_ "google.golang.org/grpc/health" // Клиентская проверка состояния экземпляров через grpc.health.v1.Health
+	"google.golang.org/grpc/resolver"
+	"google.golang.org/grpc/resolver/manual"
+)
+
+// Balancer политика выбора экземпляра сервера для каждого вызова.
+type Balancer string
+
+const (
+	// RoundRobin вызовы распределяются по всем готовым экземплярам по кругу (по умолчанию)
+	RoundRobin Balancer = roundrobin.Name
+	// LeastRequest вызов отправляется экземпляру с меньшим числом активных вызовов из двух случайных
+	LeastRequest Balancer = leastrequest.Name
+	// PickFirst все вызовы идут на первый доступный экземпляр; проверка состояния не применяется
+	PickFirst Balancer = pickfirst.Name
+)
+
+// HealthService имя сервиса в grpc.health.v1.Health, по которому клиент проверяет экземпляры.
+// Сервер сообщает SERVING, только когда ему доступны PostgreSQL и Kafka, поэтому экземпляры
+// с недоступными зависимостями исключаются из балансировки до восстановления.
+const HealthService = "service.MessageService"
+
+// staticScheme схема адреса для статического списка экземпляров (WithAddresses)
+const staticScheme = "static"
+
+// defaultReconnectBackoff параметры переподключения к экземпляру после разрыва соединения
+var defaultReconnectBackoff = backoff.Config{
+	BaseDelay:  time.Second,
+	Multiplier: 1.6,
+	Jitter:     0.2,
+	MaxDelay:   30 * time.Second,
+}
+
+// WithAddresses задаёт статический список экземпляров сервера "host:port"; вызовы распределяются
+// между ними политикой балансировки. Один адрес равнозначен WithAddress. Для обнаружения экземпляров через DNS (все A-записи имени)
+// используйте WithAddress("dns:///messages.internal:50051"): имя периодически перечитывается,
+// в том числе после разрыва соединений.
+func WithAddresses(addresses ...string) Option {
+	return func(o *options) { o.addresses = addresses }
+}
+
+// WithBalancer задаёт политику балансировки (по умолчанию RoundRobin).
+func WithBalancer(balancer Balancer) Option {
+	return func(o *options) { o.balancer = balancer }
+}
+
+// WithHealthCheck включает или отключает проверку состояния экземпляров через health-сервис
+// (по умолчанию включена). Экземпляр в статусе, отличном от SERVING, не получает вызовов.
+func WithHealthCheck(enabled bool) Option {
+	return func(o *options) { o.healthCheck = enabled }
+}
+
+// WithReconnectBackoff задаёт начальную и максимальную задержку переподключения к экземпляру
+// (по умолчанию 1 и 30 секунд). Клиент переподключается автоматически, пока не будет закрыт.
+func WithReconnectBackoff(base, max time.Duration) Option {
+	return func(o *options) {
+		o.reconnectBackoff.BaseDelay = base
+		o.reconnectBackoff.MaxDelay = max
+	}
+}
+
+// target возвращает адрес для grpc.NewClient и, для статического списка экземпляров, опцию с резолвером.
+func (o *options) target() (string, []grpc.DialOption) {
+	switch len(o.addresses) {
+	case 0:
+		return o.address, nil
+	case 1:
+		return o.addresses[0], nil
+	}
+	// Резолвер создаётся для каждого клиента и не регистрируется глобально
+	r := manual.NewBuilderWithScheme(staticScheme)
+	state := resolver.State{}
+	for _, address := range o.addresses {
+		state.Addresses = append(state.Addresses, resolver.Address{Addr: address})
+	}
+	r.InitialState(state)
+	// Первый адрес задаёт authority соединения, в том числе имя сервера для проверки TLS-сертификата
+	return staticScheme + ":///" + o.addresses[0], []grpc.DialOption{grpc.WithResolvers(r)}
+}
+
+// balancingDialOptions возвращает конфигурацию сервиса с политикой балансировки и проверкой состояния
+// и параметры переподключения.
+func (o *options) balancingDialOptions() []grpc.DialOption {
+	config := map[string]interface{}{
+		"loadBalancingConfig": []map[string]interface{}{{string(o.balancer): map[string]interface{}{}}},
+	}
+	if o.healthCheck {
+		config["healthCheckConfig"] = map[string]string{"serviceName": HealthService}
+	}
+	serviceConfig, _ := json.Marshal(config)
+
+	return []grpc.DialOption{
+		grpc.WithDefaultServiceConfig(string(serviceConfig)),
+		grpc.WithConnectParams(grpc.ConnectParams{Backoff: o.reconnectBackoff, MinConnectTimeout: 20 * time.Second}),
+	}
+}
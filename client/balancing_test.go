@@ -0,0 +1,231 @@
+package client
+
+import (
+	"context"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	pb "go_micro_gRPS/proto/go_micro_gRPC/proto"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// testTimeout ограничение времени одного теста
+const testTimeout = 10 * time.Second
+
+// backend экземпляр сервера на loopback-адресе: GetMessage возвращает номер экземпляра в поле id.
+type backend struct {
+	pb.UnimplementedMessageServiceServer
+	index   int
+	addr    string
+	health  *health.Server
+	arrived chan<- int    // Номер экземпляра для каждого поступившего вызова (если задан)
+	block   chan struct{} // Вызовы блокируются до закрытия канала (если задан)
+}
+
+func (b *backend) GetMessage(ctx context.Context, req *pb.GetMessageRequest) (*pb.Message, error) {
+	if b.arrived != nil {
+		select {
+		case b.arrived <- b.index:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	if b.block != nil {
+		select {
+		case <-b.block:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	return &pb.Message{Id: int32(b.index)}, nil
+}
+
+// startBackends запускает n экземпляров в состоянии SERVING и останавливает их по окончании теста.
+func startBackends(t *testing.T, n int) []*backend {
+	t.Helper()
+	backends := make([]*backend, n)
+	for i := range backends {
+		lis, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		b := &backend{index: i, addr: lis.Addr().String(), health: health.NewServer()}
+		b.health.SetServingStatus(HealthService, healthpb.HealthCheckResponse_SERVING)
+
+		srv := grpc.NewServer()
+		pb.RegisterMessageServiceServer(srv, b)
+		healthpb.RegisterHealthServer(srv, b.health)
+		go srv.Serve(lis)
+		t.Cleanup(srv.Stop)
+		backends[i] = b
+	}
+	return backends
+}
+
+func addresses(backends []*backend) []string {
+	addrs := make([]string, len(backends))
+	for i, b := range backends {
+		addrs[i] = b.addr
+	}
+	return addrs
+}
+
+func newTestClient(t *testing.T, backends []*backend, opts ...Option) *Client {
+	t.Helper()
+	opts = append([]Option{WithAddresses(addresses(backends)...), WithoutRetries()}, opts...)
+	c, err := New(opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { c.Close() })
+	return c
+}
+
+// callBackend выполняет вызов и возвращает номер ответившего экземпляра.
+func callBackend(t *testing.T, ctx context.Context, c *Client) int {
+	t.Helper()
+	res, err := c.Service().GetMessage(ctx, &pb.GetMessageRequest{})
+	if err != nil {
+		t.Fatalf("GetMessage: %v", err)
+	}
+	return int(res.Id)
+}
+
+// awaitBackends выполняет вызовы, пока ответы не придут от всех экземпляров want: соединения
+// с экземплярами устанавливаются и проходят проверку состояния не одновременно.
+func awaitBackends(t *testing.T, ctx context.Context, c *Client, want ...int) {
+	t.Helper()
+	seen := make(map[int]bool)
+	for len(seen) < len(want) {
+		if ctx.Err() != nil {
+			t.Fatalf("ответили экземпляры %v, ожидались %v", seen, want)
+		}
+		index := callBackend(t, ctx, c)
+		for _, w := range want {
+			if index == w {
+				seen[index] = true
+			}
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestStaticTarget(t *testing.T) {
+	o := newOptions([]Option{WithAddresses("a:1")})
+	if target, dialOptions := o.target(); target != "a:1" || dialOptions != nil {
+		t.Errorf("один адрес: target %q, опций %d", target, len(dialOptions))
+	}
+
+	o = newOptions([]Option{WithAddresses("a:1", "b:2", "c:3")})
+	target, dialOptions := o.target()
+	if !strings.HasPrefix(target, staticScheme+":///a:1") || len(dialOptions) != 1 {
+		t.Errorf("несколько адресов: target %q, опций %d", target, len(dialOptions))
+	}
+}
+
+func TestRoundRobinSpreadsCalls(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+	backends := startBackends(t, 3)
+	c := newTestClient(t, backends, WithBalancer(RoundRobin))
+	awaitBackends(t, ctx, c, 0, 1, 2)
+
+	// Когда все экземпляры готовы, round_robin распределяет вызовы поровну
+	counts := make(map[int]int)
+	for i := 0; i < 30; i++ {
+		counts[callBackend(t, ctx, c)]++
+	}
+	for _, b := range backends {
+		if counts[b.index] != 10 {
+			t.Errorf("вызовов по экземплярам %v, ожидалось по 10", counts)
+			break
+		}
+	}
+}
+
+func TestLeastRequestAvoidsBusyBackend(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+	backends := startBackends(t, 3)
+	c := newTestClient(t, backends, WithBalancer(LeastRequest))
+	awaitBackends(t, ctx, c, 0, 1, 2)
+
+	// Экземпляр 0 не завершает вызовы: накопив активные вызовы, он выбирается,
+	// только если оба случайно выбранных экземпляра - он сам
+	arrived := make(chan int)
+	for _, b := range backends {
+		b.arrived = arrived
+	}
+	backends[0].block = make(chan struct{})
+	var wg sync.WaitGroup
+	defer func() {
+		close(backends[0].block)
+		wg.Wait()
+	}()
+
+	const calls = 60
+	counts := make(map[int]int)
+	for i := 0; i < calls; i++ {
+		done := make(chan struct{})
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer close(done)
+			c.Service().GetMessage(ctx, &pb.GetMessageRequest{})
+		}()
+		var index int
+		select {
+		case index = <-arrived:
+		case <-ctx.Done():
+			t.Fatalf("вызов %d не поступил ни одному экземпляру", i)
+		}
+		counts[index]++
+		if index != 0 {
+			// Вызов завершен и не учитывается как активный при выборе следующего
+			<-done
+		}
+	}
+	// round_robin отправил бы занятому экземпляру треть вызовов
+	if counts[0] >= calls/3 {
+		t.Errorf("занятый экземпляр получил %d вызовов из %d: %v", counts[0], calls, counts)
+	}
+	if counts[1] == 0 || counts[2] == 0 {
+		t.Errorf("свободные экземпляры должны получать вызовы: %v", counts)
+	}
+}
+
+func TestHealthCheckExcludesNotServing(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+	backends := startBackends(t, 3)
+	backends[1].health.SetServingStatus(HealthService, healthpb.HealthCheckResponse_NOT_SERVING)
+	c := newTestClient(t, backends)
+	awaitBackends(t, ctx, c, 0, 2)
+
+	for i := 0; i < 30; i++ {
+		if index := callBackend(t, ctx, c); index == 1 {
+			t.Fatal("вызов отправлен экземпляру в статусе NOT_SERVING")
+		}
+	}
+
+	// После восстановления экземпляр снова получает вызовы
+	backends[1].health.SetServingStatus(HealthService, healthpb.HealthCheckResponse_SERVING)
+	awaitBackends(t, ctx, c, 1)
+}
+
+func TestHealthCheckDisabled(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+	backends := startBackends(t, 2)
+	backends[1].health.SetServingStatus(HealthService, healthpb.HealthCheckResponse_NOT_SERVING)
+	c := newTestClient(t, backends, WithHealthCheck(false))
+
+	// Без проверки состояния вызовы получает и экземпляр в статусе NOT_SERVING
+	awaitBackends(t, ctx, c, 0, 1)
+}
@@ -0,0 +1,181 @@
+// Package client Go-клиент MessageService: отправка, получение и список сообщений, статистика
+// и подписка на поток сообщений, с повторными попытками при временных ошибках.
+//
+//	c, err := client.New(client.WithAddress("localhost:50051"), client.WithAPIKey(key))
+//	if err != nil {
+//		return err
+//	}
+//	defer c.Close()
+//	res, err := c.Send(ctx, &pb.MessageRequest{Content: "Hello, gRPC!"})
+//
+// Для нескольких экземпляров сервиса клиент распределяет вызовы между ними (WithAddresses
+// или WithAddress("dns:///host:port"), политика - WithBalancer) и пропускает экземпляры,
+// которые health-сервис не считает готовыми:
+//
+//	c, err := client.New(client.WithAddresses("10.0.0.1:50051", "10.0.0.2:50051"), client.WithBalancer(client.LeastRequest))
+//
+// Ошибки возвращаются в виде gRPC-статусов (status.Code(err)).
+package client
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+
+	pb "go_micro_gRPS/proto/go_micro_gRPC/proto"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+)
+
+// IdempotencyKeyHeader ключ метаданных с ключом идемпотентности отправки
+const IdempotencyKeyHeader = "idempotency-key"
+
+// Client клиент MessageService. Безопасен для использования из нескольких горутин.
+type Client struct {
+	conn *grpc.ClientConn
+	svc  pb.MessageServiceClient
+	opts *options
+}
+
+// New создаёт клиента. Соединения с экземплярами сервера устанавливаются при первом вызове
+// и восстанавливаются автоматически.
+func New(opts ...Option) (*Client, error) {
+	o := newOptions(opts)
+	target, resolverOptions := o.target()
+	conn, err := grpc.NewClient(target, append(resolverOptions, o.grpcDialOptions()...)...)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка создания клиента %s: %v", target, err)
+	}
+	return &Client{conn: conn, svc: pb.NewMessageServiceClient(conn), opts: o}, nil
+}
+
+// Close закрывает соединение с сервером.
+func (c *Client) Close() error {
+	return c.conn.Close()
+}
+
+// Service возвращает сгенерированный клиент MessageService для вызовов без повторных попыток и тайм-аутов клиента.
+func (c *Client) Service() pb.MessageServiceClient {
+	return c.svc
+}
+
+// Send отправляет сообщение. Если в исходящих метаданных ctx нет ключа идемпотентности,
+// клиент генерирует его сам, поэтому повтор после сбоя не создаёт второе сообщение.
+func (c *Client) Send(ctx context.Context, req *pb.MessageRequest) (*pb.MessageResponse, error) {
+	if md, _ := metadata.FromOutgoingContext(ctx); len(md.Get(IdempotencyKeyHeader)) == 0 {
+		key, err := newIdempotencyKey()
+		if err != nil {
+			return nil, err
+		}
+		ctx = metadata.AppendToOutgoingContext(ctx, IdempotencyKeyHeader, key)
+	}
+
+	var res *pb.MessageResponse
+	err := c.call(ctx, isRetryable, func(ctx context.Context) (err error) {
+		res, err = c.svc.SendMessage(ctx, req)
+		return err
+	})
+	return res, err
+}
+
+// SendBatch отправляет пакет сообщений одним вызовом BatchSendMessages. Ошибки отдельных сообщений
+// возвращаются в BatchSendResponse.Failures. Пакет повторяется только после ResourceExhausted:
+// лимит проверяется при чтении пакета, до сохранения сообщений, поэтому повтор не создаёт дубликатов.
+func (c *Client) SendBatch(ctx context.Context, requests []*pb.MessageRequest) (*pb.BatchSendResponse, error) {
+	var res *pb.BatchSendResponse
+	err := c.call(ctx, isRateLimited, func(ctx context.Context) error {
+		stream, err := c.svc.BatchSendMessages(ctx)
+		if err != nil {
+			return err
+		}
+		for _, req := range requests {
+			if err := stream.Send(req); err != nil {
+				if err == io.EOF {
+					// Сервер завершил вызов; ошибка возвращается из CloseAndRecv
+					break
+				}
+				return err
+			}
+		}
+		res, err = stream.CloseAndRecv()
+		return err
+	})
+	return res, err
+}
+
+// Get возвращает сообщение по ID.
+func (c *Client) Get(ctx context.Context, id int32) (*pb.Message, error) {
+	var res *pb.Message
+	err := c.call(ctx, isRetryable, func(ctx context.Context) (err error) {
+		res, err = c.svc.GetMessage(ctx, &pb.GetMessageRequest{Id: id})
+		return err
+	})
+	return res, err
+}
+
+// List возвращает страницу сообщений по фильтру req; следующая страница запрашивается с req.PageToken = res.NextPageToken.
+func (c *Client) List(ctx context.Context, req *pb.ListMessagesRequest) (*pb.ListMessagesResponse, error) {
+	var res *pb.ListMessagesResponse
+	err := c.call(ctx, isRetryable, func(ctx context.Context) (err error) {
+		res, err = c.svc.ListMessages(ctx, req)
+		return err
+	})
+	return res, err
+}
+
+// Stats возвращает статистику сообщений топика topic (пусто - по всем топикам).
+func (c *Client) Stats(ctx context.Context, topic string) (*pb.Stats, error) {
+	var res *pb.Stats
+	err := c.call(ctx, isRetryable, func(ctx context.Context) (err error) {
+		res, err = c.svc.GetStats(ctx, &pb.StatsRequest{Topic: topic})
+		return err
+	})
+	return res, err
+}
+
+// Retry повторно отправляет в Kafka сообщение со статусом failed. Для сообщений в других статусах
+// и с истекшим сроком жизни сервер возвращает FailedPrecondition.
+func (c *Client) Retry(ctx context.Context, id int32) (*pb.Message, error) {
+	var res *pb.Message
+	err := c.call(ctx, isRetryable, func(ctx context.Context) (err error) {
+		res, err = c.svc.RetryMessage(ctx, &pb.RetryMessageRequest{Id: id})
+		return err
+	})
+	return res, err
+}
+
+// Subscribe открывает поток сообщений, прочитанных consumer'ом сервиса. Поток живёт до отмены ctx;
+// тайм-аут клиента и повторные попытки к нему не применяются, после ошибки Recv подписку нужно открыть заново.
+func (c *Client) Subscribe(ctx context.Context, req *pb.SubscribeRequest) (pb.MessageService_SubscribeMessagesClient, error) {
+	return c.svc.SubscribeMessages(ctx, req)
+}
+
+// call выполняет unary-вызов с тайм-аутом клиента и повторными попытками.
+func (c *Client) call(ctx context.Context, retryable func(codes.Code) bool, fn func(context.Context) error) error {
+	if _, ok := ctx.Deadline(); !ok && c.opts.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.opts.timeout)
+		defer cancel()
+	}
+	return c.opts.retry.do(ctx, retryable, fn)
+}
+
+func isRetryable(code codes.Code) bool {
+	return retryableCodes[code]
+}
+
+func isRateLimited(code codes.Code) bool {
+	return code == codes.ResourceExhausted
+}
+
+func newIdempotencyKey() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("ошибка генерации ключа идемпотентности: %v", err)
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,129 @@
+package client
+
+import (
+	"context"
+	"crypto/tls"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/backoff"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+)
+
+const (
+	// DefaultAddress адрес gRPC-сервера по умолчанию
+	DefaultAddress = "localhost:50051"
+	// DefaultTimeout тайм-аут unary-вызова по умолчанию, включая повторные попытки
+	DefaultTimeout = 10 * time.Second
+)
+
+// Option настройка клиента.
+type Option func(*options)
+
+type options struct {
+	address          string
+	addresses        []string
+	balancer         Balancer
+	healthCheck      bool
+	reconnectBackoff backoff.Config
+	tlsConfig        *tls.Config
+	bearerToken      string
+	apiKey           string
+	timeout          time.Duration
+	retry            RetryPolicy
+	dialOptions      []grpc.DialOption
+}
+
+func newOptions(opts []Option) *options {
+	o := &options{
+		address:          DefaultAddress,
+		balancer:         RoundRobin,
+		healthCheck:      true,
+		reconnectBackoff: defaultReconnectBackoff,
+		timeout:          DefaultTimeout,
+		retry:            DefaultRetryPolicy,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// WithAddress задаёт адрес сервера (по умолчанию localhost:50051). Поддерживаются схемы gRPC,
+// например dns:///host:port для балансировки между всеми адресами имени.
+func WithAddress(address string) Option {
+	return func(o *options) { o.address = address }
+}
+
+// WithTLS включает TLS; cfg может содержать клиентский сертификат для mTLS. Без этой опции соединение не шифруется.
+func WithTLS(cfg *tls.Config) Option {
+	return func(o *options) { o.tlsConfig = cfg }
+}
+
+// WithBearerToken передаёт JWT в метаданных authorization каждого вызова.
+func WithBearerToken(token string) Option {
+	return func(o *options) { o.bearerToken = token }
+}
+
+// WithAPIKey передаёт API-ключ в метаданных x-api-key каждого вызова.
+func WithAPIKey(key string) Option {
+	return func(o *options) { o.apiKey = key }
+}
+
+// WithTimeout задаёт тайм-аут unary-вызовов, если у контекста вызова нет своего дедлайна (0 - без тайм-аута).
+// Тайм-аут ограничивает вызов целиком, вместе с повторными попытками.
+func WithTimeout(timeout time.Duration) Option {
+	return func(o *options) { o.timeout = timeout }
+}
+
+// WithRetryPolicy задаёт политику повторных попыток (по умолчанию DefaultRetryPolicy).
+func WithRetryPolicy(policy RetryPolicy) Option {
+	return func(o *options) { o.retry = policy }
+}
+
+// WithoutRetries отключает повторные попытки.
+func WithoutRetries() Option {
+	return func(o *options) { o.retry = RetryPolicy{MaxAttempts: 1} }
+}
+
+// WithDialOptions передаёт дополнительные опции в grpc.NewClient.
+func WithDialOptions(dialOptions ...grpc.DialOption) Option {
+	return func(o *options) { o.dialOptions = append(o.dialOptions, dialOptions...) }
+}
+
+// grpcDialOptions собирает опции grpc.NewClient: транспорт, балансировку, учетные данные и пользовательские опции.
+func (o *options) grpcDialOptions() []grpc.DialOption {
+	creds := insecure.NewCredentials()
+	if o.tlsConfig != nil {
+		creds = credentials.NewTLS(o.tlsConfig)
+	}
+	dialOptions := append([]grpc.DialOption{grpc.WithTransportCredentials(creds)}, o.balancingDialOptions()...)
+	if o.bearerToken != "" || o.apiKey != "" {
+		dialOptions = append(dialOptions,
+			grpc.WithChainUnaryInterceptor(o.authUnaryInterceptor),
+			grpc.WithChainStreamInterceptor(o.authStreamInterceptor),
+		)
+	}
+	return append(dialOptions, o.dialOptions...)
+}
+
+func (o *options) authUnaryInterceptor(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	return invoker(o.withCredentials(ctx), method, req, reply, cc, opts...)
+}
+
+func (o *options) authStreamInterceptor(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+	return streamer(o.withCredentials(ctx), desc, cc, method, opts...)
+}
+
+// withCredentials добавляет учетные данные в исходящие метаданные вызова.
+func (o *options) withCredentials(ctx context.Context) context.Context {
+	if o.bearerToken != "" {
+		ctx = metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+o.bearerToken)
+	}
+	if o.apiKey != "" {
+		ctx = metadata.AppendToOutgoingContext(ctx, "x-api-key", o.apiKey)
+	}
+	return ctx
+}
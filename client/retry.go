@@ -0,0 +1,89 @@
+package client
+
+import (
+	"context"
+	"math/rand/v2"
+	"time"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// RetryPolicy параметры повторных попыток с экспоненциальной задержкой и случайным разбросом.
+type RetryPolicy struct {
+	MaxAttempts    int           // Максимальное количество попыток, включая первую (<= 1 - без повторов)
+	InitialBackoff time.Duration // Задержка перед первым повтором
+	MaxBackoff     time.Duration // Максимальная задержка
+	Multiplier     float64       // Множитель задержки после каждой попытки
+	Jitter         float64       // Доля задержки, на которую она случайно уменьшается (0..1)
+}
+
+// DefaultRetryPolicy политика повторных попыток по умолчанию.
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts:    4,
+	InitialBackoff: 100 * time.Millisecond,
+	MaxBackoff:     5 * time.Second,
+	Multiplier:     2,
+	Jitter:         0.2,
+}
+
+// retryableCodes коды временных ошибок, после которых вызов повторяется:
+// сервер или зависимость недоступны, превышен лимит запросов, конфликт транзакции.
+var retryableCodes = map[codes.Code]bool{
+	codes.Unavailable:       true,
+	codes.ResourceExhausted: true,
+	codes.Aborted:           true,
+}
+
+// do выполняет fn, повторяя её при ошибках, для которых retryable возвращает true.
+// Задержка растет экспоненциально; если сервер прислал RetryInfo, ждем не меньше указанного времени.
+// При отмене контекста во время ожидания возвращается ошибка последней попытки.
+func (p RetryPolicy) do(ctx context.Context, retryable func(codes.Code) bool, fn func(context.Context) error) error {
+	backoff := p.InitialBackoff
+	for attempt := 1; ; attempt++ {
+		err := fn(ctx)
+		if err == nil || attempt >= p.MaxAttempts || !retryable(status.Code(err)) {
+			return err
+		}
+
+		delay := p.jitter(backoff)
+		if serverDelay := retryDelay(err); serverDelay > delay {
+			delay = serverDelay
+		}
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+
+		backoff = time.Duration(float64(backoff) * p.Multiplier)
+		if p.MaxBackoff > 0 && backoff > p.MaxBackoff {
+			backoff = p.MaxBackoff
+		}
+	}
+}
+
+// jitter случайно уменьшает задержку на долю до Jitter, чтобы клиенты не повторяли запросы одновременно.
+func (p RetryPolicy) jitter(d time.Duration) time.Duration {
+	if p.Jitter <= 0 || d <= 0 {
+		return d
+	}
+	return d - time.Duration(rand.Float64()*p.Jitter*float64(d))
+}
+
+// retryDelay возвращает задержку из RetryInfo статуса ошибки или 0.
+func retryDelay(err error) time.Duration {
+	st, ok := status.FromError(err)
+	if !ok {
+		return 0
+	}
+	for _, detail := range st.Details() {
+		if info, ok := detail.(*errdetails.RetryInfo); ok {
+			return info.RetryDelay.AsDuration()
+		}
+	}
+	return 0
+}
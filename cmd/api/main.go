@@ -3,13 +3,18 @@ package main
 
 import (
 	"context"
+	"crypto/tls"
 	"errors"
 	httpSwagger "github.com/swaggo/http-swagger"
 	"go_micro_gRPS/config"
 	_ "go_micro_gRPS/docs"
+	"go_micro_gRPS/internal/auth"
 	"go_micro_gRPS/internal/database"
 	"go_micro_gRPS/internal/handlers"
 	"go_micro_gRPS/internal/kafka_services"
+	"go_micro_gRPS/internal/ratelimit"
+	"go_micro_gRPS/internal/tlsutil"
+	pb "go_micro_gRPS/proto/go_micro_gRPC/proto"
 	"go_micro_gRPS/server"
 	"log"
 	"net/http"
@@ -26,7 +31,7 @@ func main() {
 	defer cancel()
 
 	// Инициализация базы данных
-	db, err := database.ConnectPostgres(ctx)
+	db, err := database.ConnectPostgres(ctx, cfg)
 	if err != nil {
 		log.Fatal("Ошибка подключения к базе данных:", err)
 	}
@@ -40,15 +45,23 @@ func main() {
 	brokers := []string{cfg.KafkaBrokers}
 	topic := cfg.KafkaTopic
 
-	// Вызов функции создания топика
-	err = kafka_services.CreateKafkaTopic(brokers, topic, 1, 1) // brokers[0]
-	if err != nil {
-		log.Fatalf("Ошибка создания топика: %v\n", err)
+	// Создание топиков всех полос приоритета для топиков, в которые разрешена отправка и на которые подписан consumer.
+	// Порядок записей с одним ключом сохраняется только внутри партиции (см. kafka_services/partitioning.go)
+	created := make(map[string]bool)
+	for _, t := range kafka_services.LaneTopics(append(cfg.KafkaTopics, cfg.KafkaConsumerTopics...)) {
+		if created[t] {
+			continue
+		}
+		created[t] = true
+		err = kafka_services.CreateKafkaTopic(brokers, t, cfg.KafkaTopicPartitions, cfg.KafkaTopicReplicationFactor)
+		if err != nil {
+			log.Fatalf("Ошибка создания топика %s: %v\n", t, err)
+		}
 	}
 
 	groupID := "consumer_group_1"
-	// Создаём consumer
-	consumer := kafka_services.NewKafkaConsumer(brokers, topic, groupID)
+	// Создаём consumer, подписанный на полосы приоритета топиков KafkaConsumerTopics
+	consumer := kafka_services.NewKafkaConsumer(brokers, cfg.KafkaConsumerTopics, groupID)
 	defer consumer.Close()
 
 	// Запускаем чтение сообщений в отдельной горутине
@@ -56,31 +69,163 @@ func main() {
 		consumer.ReadMessages(ctx)
 	}()
 
-	// Инициализация Kafka producer
-	kafkaProducer := kafka_services.NewKafkaProducer(ctx, brokers, topic)
+	// Инициализация Kafka producer. В асинхронном режиме статус сообщений SendMessage обновляется
+	// по отчётам о доставке: 'processed' после подтверждения Kafka или 'failed'
+	// Ключ записи и партиция выбираются по KAFKA_KEY_STRATEGY и KAFKA_BALANCER (см. kafka_services/partitioning.go)
+	keyStrategy, err := kafka_services.ParseKeyStrategy(cfg.KafkaKeyStrategy)
+	if err != nil {
+		log.Fatalf("Ошибка настройки Kafka producer: %v", err)
+	}
+	balancer, err := kafka_services.ParseBalancer(cfg.KafkaBalancer)
+	if err != nil {
+		log.Fatalf("Ошибка настройки Kafka producer: %v", err)
+	}
+	producerConfig := kafka_services.ProducerConfig{
+		BatchSize:    cfg.KafkaProducerBatchSize,
+		BatchTimeout: cfg.KafkaProducerBatchTimeout,
+		Compression:  cfg.KafkaProducerCompression,
+		KeyStrategy:  keyStrategy,
+		Balancer:     balancer,
+		Async:        cfg.KafkaProducerAsync,
+	}
+	var deliveries *server.DeliveryRecorder
+	if cfg.KafkaProducerAsync {
+		deliveries = server.NewDeliveryRecorder(db)
+		producerConfig.OnDelivery = deliveries.Record
+		log.Printf("Асинхронная отправка в Kafka включена: пакет до %d записей, ожидание %v",
+			cfg.KafkaProducerBatchSize, cfg.KafkaProducerBatchTimeout)
+	}
+	kafkaProducer, err := kafka_services.NewKafkaProducer(brokers, topic, producerConfig)
+	if err != nil {
+		log.Fatalf("Ошибка создания Kafka producer: %v", err)
+	}
+	// Producer закрывается один раз при завершении; закрытие отправляет накопленные записи,
+	// после чего дописываются их отчёты о доставке
 	defer func() {
+		log.Println("Shutting down Kafka producer...")
 		if err := kafkaProducer.Close(); err != nil {
 			log.Printf("Ошибка закрытия Kafka producer: %v", err)
 		}
+		if deliveries != nil {
+			deliveries.Close()
+		}
 	}()
 
+	// Планировщик отправки отложенных сообщений и истечения срока жизни; может работать в нескольких экземплярах сервиса
+	if cfg.SchedulerEnabled {
+		go server.NewScheduler(db, kafkaProducer, cfg.SchedulerInterval, cfg.SchedulerBatchSize).Run(ctx)
+	}
+
+	// Опции gRPC-сервера
+	grpcOptions := []server.Option{
+		server.WithAccessLog(cfg.GRPCAccessLog),
+		server.WithIdempotencyRetention(cfg.IdempotencyRetention),
+		server.WithTopics(topic, cfg.KafkaTopics),
+	}
+
+	// Удаление истекших ключей идемпотентности
+	go server.CleanupIdempotencyKeys(ctx, db, cfg.IdempotencyRetention)
+
+	// TLS (и mTLS при заданном CA клиентов) с перезагрузкой сертификатов при изменении файлов
+	if cfg.GRPCTLSCert != "" {
+		clientAuth, err := tlsutil.ParseClientAuth(cfg.GRPCTLSClientAuth)
+		if err != nil {
+			log.Fatalf("Ошибка настройки TLS: %v", err)
+		}
+		if clientAuth == tls.NoClientCert && cfg.GRPCTLSClientCA != "" {
+			clientAuth = tls.RequireAndVerifyClientCert
+		}
+
+		reloader, err := tlsutil.NewReloader(cfg.GRPCTLSCert, cfg.GRPCTLSKey, cfg.GRPCTLSClientCA)
+		if err != nil {
+			log.Fatalf("Ошибка загрузки TLS-сертификатов: %v", err)
+		}
+		if cfg.GRPCTLSReloadInterval > 0 {
+			go reloader.Watch(ctx, cfg.GRPCTLSReloadInterval)
+		} else {
+			log.Println("Перезагрузка TLS-сертификатов отключена (GRPC_TLS_RELOAD_INTERVAL <= 0)")
+		}
+
+		grpcOptions = append(grpcOptions, server.WithTLS(reloader.ServerConfig(clientAuth)))
+		log.Printf("TLS для gRPC включен (проверка клиентов: %v)", clientAuth)
+	}
+
+	// Аутентификация и авторизация по ролям для gRPC и HTTP
+	protect := func(h http.Handler) http.Handler { return h }
+	if cfg.AuthEnabled {
+		authorizer, err := auth.NewAuthorizerFromConfig(cfg)
+		if err != nil {
+			log.Fatalf("Ошибка настройки аутентификации: %v", err)
+		}
+		grpcOptions = append(grpcOptions,
+			server.WithUnaryInterceptors(authorizer.UnaryServerInterceptor),
+			server.WithStreamInterceptors(authorizer.StreamServerInterceptor),
+		)
+		protect = authorizer.HTTPMiddleware
+		log.Println("Аутентификация включена")
+	}
+
+	// Ограничение частоты отправки сообщений по клиентам; перехватчики идут после аутентификации,
+	// чтобы клиент определялся по аутентифицированному subject
+	if cfg.RateLimitEnabled {
+		var clientLimits map[string]ratelimit.Limit
+		if cfg.RateLimitClientsFile != "" {
+			clientLimits, err = ratelimit.LoadClientLimits(cfg.RateLimitClientsFile)
+			if err != nil {
+				log.Fatalf("Ошибка загрузки лимитов клиентов: %v", err)
+			}
+		}
+		limiter := ratelimit.NewLimiter(ratelimit.Limit{RPS: cfg.RateLimitRPS, Burst: cfg.RateLimitBurst}, clientLimits)
+		go limiter.Cleanup(ctx)
+
+		grpcLimiter := ratelimit.NewGRPCLimiter(limiter,
+			pb.MessageService_SendMessage_FullMethodName,
+			pb.MessageService_SendMessageStream_FullMethodName,
+			pb.MessageService_BatchSendMessages_FullMethodName,
+			pb.MessageService_RetryMessage_FullMethodName,
+		)
+		grpcOptions = append(grpcOptions,
+			server.WithUnaryInterceptors(grpcLimiter.UnaryServerInterceptor),
+			server.WithStreamInterceptors(grpcLimiter.StreamServerInterceptor),
+		)
+
+		// curl http://localhost:8080/api/ratelimits
+		http.Handle("/api/ratelimits", protect(limiter.UsageHandler()))
+		log.Printf("Ограничение частоты запросов включено: %.f rps, burst %d", cfg.RateLimitRPS, cfg.RateLimitBurst)
+	}
+
 	// Запуск gRPC-сервера
-	go server.StartGRPCServer(db, kafkaProducer)
+	go server.StartGRPCServer(ctx, db, kafkaProducer, consumer, brokers, grpcOptions...)
+
+	// REST API, транскодируемый в вызовы gRPC-сервиса по HTTP-аннотациям service.proto.
+	// Аутентификация и лимиты применяются перехватчиками gRPC, как и для прямых вызовов.
+	gateway, err := server.NewGatewayHandler(ctx, db, kafkaProducer, consumer, grpcOptions...)
+	if err != nil {
+		log.Fatalf("Ошибка создания HTTP-шлюза: %v", err)
+	}
 
 	// Ручка для Swagger UI
-	// export PATH=$PATH:$(go env GOPATH)/bin
-	// swag init -g cmd/api/main.go -o docs
+	// Код gRPC, шлюза и Swagger-описание генерируются из proto:
+	// cd proto && protoc -I . -I <googleapis> -I <grpc-gateway> --go_out=. --go-grpc_out=. --grpc-gateway_out=. \
+	//   --openapiv2_out=../docs --openapiv2_opt=json_names_for_fields=false service.proto
 	http.HandleFunc("/swagger/", httpSwagger.WrapHandler)
 
 	// curl -X POST http://localhost:8080/api/messages -d '{"content": "Hello, World!"}' -H "Content-Type: application/json"
-	http.HandleFunc("/api/messages", handlers.PostMessageHTTPHandler(kafkaProducer, db))
-	// {"id":"2","status":"message sent successfully"}
-
+	// Ключ, атрибуты и тип содержимого передаются в ключе и заголовках записи Kafka:
+	// -d '{"content": "{}", "key": "order-42", "attributes": {"type": "order"}, "content_type": "application/json"}'
+	// Отложенная отправка: -d '{"content": "Reminder", "deliver_at": "2030-01-01T09:00:00Z"}' или "delay": "3600s"
+	// Срок жизни: "ttl": "600s" - не обработанное за это время сообщение получает статус expired
+	// Топик из списка KAFKA_TOPICS: "topic": "orders" (без топика - KAFKA_TOPIC)
+	// Приоритет: "priority": "high" - сообщение идет в полосу orders.high, которую consumer читает первой
+	// Повтор с тем же заголовком -H "Idempotency-Key: <key>" возвращает исходный ответ без нового сообщения
+	// Для запросов с аутентификацией: -H "Authorization: Bearer <token>" или -H "X-API-Key: <key>"
+	// {"status":"Message sent successfully","id":2}
 	// curl http://localhost:8080/api/stats
-	http.HandleFunc("/api/stats", handlers.GetStatsHTTPHandler(db))
-	// {"processed_messages":1}
+	// {"status_counts":{"processed":1},"total_count":"1","throughput":[{"window":"1m",...}],"latency":{"p50_ms":12.5,...}}
+	// Статистика и список сообщений одного топика: /api/stats?topic=orders, /api/messages?topics=orders
+	http.Handle("/api/", gateway)
 
-	http.HandleFunc("/api/consume", handlers.ConsumeMessagesHandler(consumer))
+	http.Handle("/api/consume", protect(handlers.ConsumeMessagesHandler(consumer)))
 
 	// Канал для получения системных сигналов для корректного завершения работы
 	quit := make(chan os.Signal, 1)
@@ -103,6 +248,9 @@ func main() {
 	<-quit
 	log.Println("Завершение работы сервера...")
 
+	// Отмена контекста останавливает consumer, health-проверки и gRPC-сервер
+	cancel()
+
 	// Контекст для корректного завершения сервера
 	ctxShutdown, cancelShutdown := context.WithTimeout(context.Background(), time.Second)
 	defer cancelShutdown()
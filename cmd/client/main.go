@@ -0,0 +1,67 @@
+// Пример использования пакета client: отправка сообщения и получение статистики.
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"strings"
+	"time"
+
+	"go_micro_gRPS/client"                       // Go-клиент MessageService
+	"go_micro_gRPS/internal/tlsutil"             // Настройка TLS
+	pb "go_micro_gRPS/proto/go_micro_gRPC/proto" // Импорт сгенерированных протофайлов
+)
+
+func main() {
+	// Параметры подключения
+	// go run ./cmd/client -tls -ca certs/ca.pem -cert certs/client.pem -key certs/client-key.pem
+	// go run ./cmd/client -addr localhost:50051,localhost:50052 -lb least_request_experimental
+	addr := flag.String("addr", client.DefaultAddress, "адрес gRPC сервера, dns:///host:port или список адресов через запятую")
+	balancer := flag.String("lb", string(client.RoundRobin), "политика балансировки: round_robin, least_request_experimental, pick_first")
+	useTLS := flag.Bool("tls", false, "подключаться по TLS")
+	caFile := flag.String("ca", "", "CA для проверки сертификата сервера (по умолчанию системный)")
+	certFile := flag.String("cert", "", "клиентский сертификат для mTLS")
+	keyFile := flag.String("key", "", "ключ клиентского сертификата для mTLS")
+	serverName := flag.String("server-name", "", "имя сервера для проверки сертификата (по умолчанию из адреса)")
+	token := flag.String("token", "", "JWT для аутентификации")
+	apiKey := flag.String("api-key", "", "API-ключ для аутентификации")
+	flag.Parse()
+
+	opts := []client.Option{
+		client.WithAddresses(strings.Split(*addr, ",")...),
+		client.WithBalancer(client.Balancer(*balancer)),
+		client.WithBearerToken(*token),
+		client.WithAPIKey(*apiKey),
+	}
+	if *useTLS {
+		tlsConfig, err := tlsutil.ClientConfig(*caFile, *certFile, *keyFile, *serverName)
+		if err != nil {
+			log.Fatalf("Ошибка настройки TLS: %v", err)
+		}
+		opts = append(opts, client.WithTLS(tlsConfig))
+	}
+
+	c, err := client.New(opts...)
+	if err != nil {
+		log.Fatalf("Не удалось создать клиента: %v", err)
+	}
+	defer c.Close() // Закрываем соединение при завершении работы программы
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// Пример отправки сообщения; при временной ошибке клиент повторит вызов с тем же ключом идемпотентности
+	res, err := c.Send(ctx, &pb.MessageRequest{Content: "Hello, gRPC!"})
+	if err != nil {
+		log.Fatalf("Ошибка отправки сообщения: %v", err)
+	}
+	log.Printf("Ответ от сервера: %s, ID: %d", res.Status, res.Id)
+
+	// Пример получения статистики сообщений
+	stats, err := c.Stats(ctx, "")
+	if err != nil {
+		log.Fatalf("Ошибка получения статистики: %v", err)
+	}
+	log.Printf("Количество обработанных сообщений: %d из %d", stats.StatusCounts["processed"], stats.TotalCount)
+}
@@ -0,0 +1,176 @@
+// loadgen генератор нагрузки на MessageService: отправляет сообщения по gRPC (SendMessage,
+// SendMessageStream, BatchSendMessages) или через REST (POST /api/messages) с заданной скоростью
+// или параллельностью и выводит пропускную способность, ошибки по кодам и распределение задержек.
+//
+//	loadgen -mode unary -c 50 -d 30s
+//	loadgen -mode stream -rate 5000 -size 1024 -d 1m
+//	loadgen -mode batch -batch 500 -c 4 -n 200
+//	loadgen -mode http -url http://localhost:8080 -rate 1000
+//
+// Без -rate каждый из -c воркеров отправляет следующий запрос сразу после ответа на предыдущий
+// (замкнутый цикл), что показывает предельную пропускную способность. С -rate запросы выдаются
+// с постоянной скоростью; если воркеров не хватает, фактическая скорость будет ниже заданной.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"math/rand/v2"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"go_micro_gRPS/client"
+	"go_micro_gRPS/internal/tlsutil"
+)
+
+// Режимы нагрузки
+const (
+	modeUnary  = "unary"  // SendMessage
+	modeStream = "stream" // SendMessageStream, одно сообщение на операцию
+	modeBatch  = "batch"  // BatchSendMessages, -batch сообщений на операцию
+	modeHTTP   = "http"   // POST /api/messages
+)
+
+// settings параметры запуска.
+type settings struct {
+	mode        string
+	concurrency int
+	conns       int
+	rate        float64
+	duration    time.Duration
+	requests    int64
+	size        int
+	batchSize   int
+	timeout     time.Duration
+	url         string
+	token       string
+	apiKey      string
+	topic       string
+	priority    string
+	payload     string
+}
+
+func main() {
+	var s settings
+	flag.StringVar(&s.mode, "mode", modeUnary, "режим: unary, stream, batch, http")
+	flag.IntVar(&s.concurrency, "c", 10, "количество воркеров (одновременных запросов или потоков)")
+	flag.IntVar(&s.conns, "conns", 1, "количество gRPC-соединений, между которыми распределяются воркеры")
+	flag.Float64Var(&s.rate, "rate", 0, "операций в секунду на все воркеры (0 - без ограничения)")
+	flag.DurationVar(&s.duration, "d", 10*time.Second, "длительность нагрузки")
+	flag.Int64Var(&s.requests, "n", 0, "общее количество операций (0 - ограничение только по -d)")
+	flag.IntVar(&s.size, "size", 256, "размер содержимого сообщения в байтах")
+	flag.IntVar(&s.batchSize, "batch", 100, "сообщений в одном вызове для режима batch")
+	flag.DurationVar(&s.timeout, "timeout", 10*time.Second, "тайм-аут одной операции")
+	flag.StringVar(&s.url, "url", "http://localhost:8080", "адрес HTTP API для режима http")
+	flag.StringVar(&s.topic, "topic", "", "топик сообщений (пусто - топик по умолчанию)")
+	flag.StringVar(&s.priority, "priority", "", "приоритет сообщений: high, normal, low")
+	addr := flag.String("addr", client.DefaultAddress, "адрес gRPC сервера, dns:///host:port или список адресов через запятую")
+	balancer := flag.String("lb", string(client.RoundRobin), "политика балансировки: round_robin, least_request_experimental, pick_first")
+	flag.StringVar(&s.token, "token", os.Getenv("LOADGEN_TOKEN"), "JWT для аутентификации (LOADGEN_TOKEN)")
+	flag.StringVar(&s.apiKey, "api-key", os.Getenv("LOADGEN_API_KEY"), "API-ключ для аутентификации (LOADGEN_API_KEY)")
+	useTLS := flag.Bool("tls", false, "подключаться по TLS")
+	caFile := flag.String("ca", "", "CA для проверки сертификата сервера (по умолчанию системный)")
+	certFile := flag.String("cert", "", "клиентский сертификат для mTLS")
+	keyFile := flag.String("key", "", "ключ клиентского сертификата для mTLS")
+	serverName := flag.String("server-name", "", "имя сервера для проверки сертификата (по умолчанию из адреса)")
+	flag.Parse()
+
+	if s.concurrency < 1 || s.conns < 1 || s.size < 0 || s.batchSize < 1 || s.rate < 0 {
+		fatal(fmt.Errorf("-c, -conns и -batch должны быть положительными, -size и -rate - неотрицательными"))
+	}
+	s.payload = randomPayload(s.size)
+
+	clientOpts := []client.Option{
+		client.WithAddresses(strings.Split(*addr, ",")...),
+		client.WithBalancer(client.Balancer(*balancer)),
+		client.WithBearerToken(s.token),
+		client.WithAPIKey(s.apiKey),
+		// Повторные попытки скрыли бы ошибки сервера, поэтому вызовы идут без них
+		client.WithoutRetries(),
+	}
+	var workers []worker
+	switch s.mode {
+	case modeUnary, modeStream, modeBatch:
+		if *useTLS {
+			tlsConfig, err := tlsutil.ClientConfig(*caFile, *certFile, *keyFile, *serverName)
+			if err != nil {
+				fatal(fmt.Errorf("ошибка настройки TLS: %v", err))
+			}
+			clientOpts = append(clientOpts, client.WithTLS(tlsConfig))
+		}
+		// Каждый клиент - отдельное соединение; один поток HTTP/2 может ограничивать пропускную способность
+		clients := make([]*client.Client, s.conns)
+		for i := range clients {
+			c, err := client.New(clientOpts...)
+			if err != nil {
+				fatal(err)
+			}
+			defer c.Close()
+			clients[i] = c
+		}
+		for i := 0; i < s.concurrency; i++ {
+			workers = append(workers, newGRPCWorker(&s, clients[i%len(clients)]))
+		}
+	case modeHTTP:
+		h, err := newHTTPTarget(&s, *useTLS, *caFile, *certFile, *keyFile, *serverName)
+		if err != nil {
+			fatal(err)
+		}
+		for i := 0; i < s.concurrency; i++ {
+			workers = append(workers, h)
+		}
+	default:
+		fatal(fmt.Errorf("неизвестный режим %q: допустимы unary, stream, batch, http", s.mode))
+	}
+
+	// Ctrl+C завершает нагрузку досрочно с выводом отчета
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	ctx, cancel := context.WithTimeout(ctx, s.duration)
+	defer cancel()
+
+	rep := run(ctx, &s, workers)
+	rep.print(os.Stdout, &s)
+}
+
+// run запускает воркеров и ждет окончания нагрузки; результаты воркеров объединяются в отчет.
+func run(ctx context.Context, s *settings, workers []worker) *report {
+	p := newPacer(ctx, s.rate, s.requests)
+	progress := &counters{}
+	go progress.print(ctx, os.Stderr, time.Second)
+
+	recorders := make([]*recorder, len(workers))
+	var wg sync.WaitGroup
+	started := time.Now()
+	for i, w := range workers {
+		recorders[i] = newRecorder(progress)
+		wg.Add(1)
+		go func(w worker, rec *recorder) {
+			defer wg.Done()
+			w.run(ctx, p, rec)
+		}(w, recorders[i])
+	}
+	wg.Wait()
+	return newReport(recorders, time.Since(started))
+}
+
+// randomPayload возвращает содержимое сообщения из случайных латинских букв.
+func randomPayload(size int) string {
+	const letters = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ"
+	b := make([]byte, size)
+	for i := range b {
+		b[i] = letters[rand.IntN(len(letters))]
+	}
+	return string(b)
+}
+
+// fatal выводит ошибку и завершает программу с кодом 1.
+func fatal(err error) {
+	fmt.Fprintf(os.Stderr, "loadgen: %v\n", err)
+	os.Exit(1)
+}
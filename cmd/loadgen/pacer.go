@@ -0,0 +1,67 @@
+package main
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+)
+
+// pacerTick период, с которым выдаются разрешения при ограничении скорости
+const pacerTick = time.Millisecond
+
+// pacer выдает воркерам разрешения на операции: с постоянной скоростью rate
+// и не больше limit операций всего (0 - без ограничений).
+type pacer struct {
+	tokens chan struct{}
+	limit  int64
+	issued atomic.Int64
+}
+
+func newPacer(ctx context.Context, rate float64, limit int64) *pacer {
+	p := &pacer{limit: limit}
+	if rate > 0 {
+		p.tokens = make(chan struct{})
+		go p.emit(ctx, rate)
+	}
+	return p
+}
+
+// next ждет разрешения на следующую операцию; false - нагрузка закончена.
+func (p *pacer) next(ctx context.Context) bool {
+	if p.limit > 0 && p.issued.Add(1) > p.limit {
+		return false
+	}
+	if p.tokens == nil {
+		return ctx.Err() == nil
+	}
+	select {
+	case <-p.tokens:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// emit выдает разрешения так, чтобы к каждому моменту было выдано rate * прошедшее время операций.
+// Если все воркеры заняты, emit ждет освободившегося воркера, после чего наверстывает отставание.
+func (p *pacer) emit(ctx context.Context, rate float64) {
+	ticker := time.NewTicker(pacerTick)
+	defer ticker.Stop()
+	started := time.Now()
+	var sent int64
+	for {
+		due := int64(time.Since(started).Seconds() * rate)
+		for ; sent < due; sent++ {
+			select {
+			case p.tokens <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+		}
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
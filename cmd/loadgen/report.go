@@ -0,0 +1,205 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"text/tabwriter"
+	"time"
+)
+
+// histogramBounds верхние границы интервалов гистограммы задержек (ряд 1-2-5)
+var histogramBounds = []time.Duration{
+	100 * time.Microsecond, 200 * time.Microsecond, 500 * time.Microsecond,
+	time.Millisecond, 2 * time.Millisecond, 5 * time.Millisecond,
+	10 * time.Millisecond, 20 * time.Millisecond, 50 * time.Millisecond,
+	100 * time.Millisecond, 200 * time.Millisecond, 500 * time.Millisecond,
+	time.Second, 2 * time.Second, 5 * time.Second, 10 * time.Second,
+}
+
+// histogramWidth ширина самого длинного столбца гистограммы в символах
+const histogramWidth = 40
+
+// percentiles выводимые перцентили задержки
+var percentiles = []float64{50, 90, 99, 99.9}
+
+// counters общие счетчики для вывода хода нагрузки.
+type counters struct {
+	calls  atomic.Int64
+	errors atomic.Int64
+}
+
+// print раз в interval выводит количество операций и ошибок за прошедший интервал.
+func (c *counters) print(ctx context.Context, w io.Writer, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	started := time.Now()
+	var calls, errors int64
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			total, failed := c.calls.Load(), c.errors.Load()
+			fmt.Fprintf(w, "%6.0fs  %8.0f оп/с  ошибок %d\n",
+				time.Since(started).Seconds(), float64(total-calls)/interval.Seconds(), failed-errors)
+			calls, errors = total, failed
+		}
+	}
+}
+
+// recorder результаты одного воркера. Запись идет без блокировок: в каждый момент recorder
+// использует одна горутина (у воркера stream - горутина чтения ack).
+type recorder struct {
+	progress  *counters
+	latencies []time.Duration
+	calls     int64
+	messages  int64
+	errors    map[string]int64
+}
+
+func newRecorder(progress *counters) *recorder {
+	return &recorder{progress: progress, errors: make(map[string]int64)}
+}
+
+// call записывает завершенную операцию: задержку, количество принятых сервером сообщений
+// и ключ ошибки (пусто при успехе).
+func (r *recorder) call(latency time.Duration, messages int, code string) {
+	r.calls++
+	r.messages += int64(messages)
+	r.latencies = append(r.latencies, latency)
+	r.progress.calls.Add(1)
+	if code != "" {
+		r.failure(code)
+	}
+}
+
+// failure записывает ошибку без задержки: ошибку открытия потока или отдельного сообщения пакета.
+func (r *recorder) failure(code string) {
+	r.errors[code]++
+	r.progress.errors.Add(1)
+}
+
+// report итоговые результаты всех воркеров.
+type report struct {
+	elapsed   time.Duration
+	calls     int64
+	messages  int64
+	errors    map[string]int64
+	latencies []time.Duration // По возрастанию
+}
+
+func newReport(recorders []*recorder, elapsed time.Duration) *report {
+	rep := &report{elapsed: elapsed, errors: make(map[string]int64)}
+	for _, r := range recorders {
+		rep.calls += r.calls
+		rep.messages += r.messages
+		rep.latencies = append(rep.latencies, r.latencies...)
+		for code, n := range r.errors {
+			rep.errors[code] += n
+		}
+	}
+	sort.Slice(rep.latencies, func(i, j int) bool { return rep.latencies[i] < rep.latencies[j] })
+	return rep
+}
+
+// percentile возвращает задержку, не превышенную в p процентах операций.
+func (r *report) percentile(p float64) time.Duration {
+	if len(r.latencies) == 0 {
+		return 0
+	}
+	i := int(math.Ceil(p/100*float64(len(r.latencies)))) - 1
+	return r.latencies[max(i, 0)]
+}
+
+func (r *report) mean() time.Duration {
+	if len(r.latencies) == 0 {
+		return 0
+	}
+	var sum time.Duration
+	for _, l := range r.latencies {
+		sum += l
+	}
+	return sum / time.Duration(len(r.latencies))
+}
+
+func (r *report) print(w io.Writer, s *settings) {
+	seconds := r.elapsed.Seconds()
+	rate := "без ограничения"
+	if s.rate > 0 {
+		rate = fmt.Sprintf("%.f оп/с", s.rate)
+	}
+	fmt.Fprintf(w, "\nРежим %s, воркеров %d, скорость %s, сообщение %d байт\n", s.mode, s.concurrency, rate, s.size)
+
+	t := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(t, "Длительность\t%s\n", r.elapsed.Round(time.Millisecond))
+	fmt.Fprintf(t, "Операций\t%d (%.1f оп/с)\n", r.calls, float64(r.calls)/seconds)
+	fmt.Fprintf(t, "Принято сообщений\t%d (%.1f сообщ./с)\n", r.messages, float64(r.messages)/seconds)
+	t.Flush()
+
+	if len(r.latencies) > 0 {
+		fmt.Fprintf(w, "\nЗадержка:\n")
+		fmt.Fprintf(t, "  min\t%s\n", r.latencies[0].Round(time.Microsecond))
+		fmt.Fprintf(t, "  mean\t%s\n", r.mean().Round(time.Microsecond))
+		for _, p := range percentiles {
+			fmt.Fprintf(t, "  p%g\t%s\n", p, r.percentile(p).Round(time.Microsecond))
+		}
+		fmt.Fprintf(t, "  max\t%s\n", r.latencies[len(r.latencies)-1].Round(time.Microsecond))
+		t.Flush()
+
+		fmt.Fprintf(w, "\nГистограмма задержек:\n")
+		r.printHistogram(t)
+		t.Flush()
+	}
+
+	if len(r.errors) > 0 {
+		fmt.Fprintf(w, "\nОшибки:\n")
+		codes := make([]string, 0, len(r.errors))
+		for code := range r.errors {
+			codes = append(codes, code)
+		}
+		// По убыванию количества, затем по коду
+		sort.Slice(codes, func(i, j int) bool {
+			if r.errors[codes[i]] != r.errors[codes[j]] {
+				return r.errors[codes[i]] > r.errors[codes[j]]
+			}
+			return codes[i] < codes[j]
+		})
+		for _, code := range codes {
+			fmt.Fprintf(t, "  %s\t%d\n", code, r.errors[code])
+		}
+		t.Flush()
+	}
+}
+
+// printHistogram выводит количество операций по интервалам задержки, пропуская пустые интервалы по краям.
+func (r *report) printHistogram(t io.Writer) {
+	counts := make([]int64, len(histogramBounds)+1)
+	for _, l := range r.latencies {
+		counts[sort.Search(len(histogramBounds), func(i int) bool { return l <= histogramBounds[i] })]++
+	}
+	first, last := 0, len(counts)-1
+	for counts[first] == 0 {
+		first++
+	}
+	for counts[last] == 0 {
+		last--
+	}
+	var peak, cumulative int64
+	for _, n := range counts {
+		peak = max(peak, n)
+	}
+	for i := first; i <= last; i++ {
+		label := "> " + histogramBounds[len(histogramBounds)-1].String()
+		if i < len(histogramBounds) {
+			label = "<= " + histogramBounds[i].String()
+		}
+		cumulative += counts[i]
+		bar := strings.Repeat("#", int(counts[i]*histogramWidth/peak))
+		fmt.Fprintf(t, "  %s\t%d\t%5.1f%%\t%s\n", label, counts[i], float64(cumulative)*100/float64(len(r.latencies)), bar)
+	}
+}
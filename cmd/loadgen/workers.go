@@ -0,0 +1,285 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"go_micro_gRPS/client"
+	"go_micro_gRPS/internal/tlsutil"
+	pb "go_micro_gRPS/proto/go_micro_gRPC/proto"
+
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+// streamReopenDelay пауза перед повторным открытием потока после ошибки
+const streamReopenDelay = 100 * time.Millisecond
+
+// Ключи ошибок отдельных сообщений, не имеющих своего gRPC-кода
+const (
+	errAckFailed  = "ack failed"  // MessageAck со статусом failed в режиме stream
+	errNoAck      = "no ack"      // Поток режима stream завершен сервером без ack сообщения
+	errItemFailed = "item failed" // BatchItemFailure в режиме batch
+)
+
+// worker выполняет операции, пока pacer выдает разрешения, и записывает результаты в rec.
+type worker interface {
+	run(ctx context.Context, p *pacer, rec *recorder)
+}
+
+// grpcWorker воркер режимов unary, stream и batch.
+type grpcWorker struct {
+	s      *settings
+	client *client.Client
+}
+
+func newGRPCWorker(s *settings, c *client.Client) *grpcWorker {
+	return &grpcWorker{s: s, client: c}
+}
+
+func (w *grpcWorker) run(ctx context.Context, p *pacer, rec *recorder) {
+	switch w.s.mode {
+	case modeStream:
+		// Поток открывается заново после ошибки, пока нагрузка не закончена
+		for ctx.Err() == nil && w.stream(ctx, p, rec) {
+		}
+	case modeBatch:
+		for p.next(ctx) {
+			w.batch(ctx, rec)
+		}
+	default:
+		for p.next(ctx) {
+			w.unary(ctx, rec)
+		}
+	}
+}
+
+func (w *grpcWorker) request() *pb.MessageRequest {
+	return &pb.MessageRequest{Content: w.s.payload, Topic: w.s.topic, Priority: w.s.priority}
+}
+
+func (w *grpcWorker) unary(ctx context.Context, rec *recorder) {
+	callCtx, cancel := context.WithTimeout(ctx, w.s.timeout)
+	defer cancel()
+	started := time.Now()
+	_, err := w.client.Service().SendMessage(callCtx, w.request())
+	if ctx.Err() != nil {
+		// Вызов прерван окончанием нагрузки и не учитывается
+		return
+	}
+	if err != nil {
+		rec.call(time.Since(started), 0, grpcCode(err))
+		return
+	}
+	rec.call(time.Since(started), 1, "")
+}
+
+func (w *grpcWorker) batch(ctx context.Context, rec *recorder) {
+	callCtx, cancel := context.WithTimeout(ctx, w.s.timeout)
+	defer cancel()
+	started := time.Now()
+	res, err := w.sendBatch(callCtx)
+	if ctx.Err() != nil {
+		return
+	}
+	if err != nil {
+		rec.call(time.Since(started), 0, grpcCode(err))
+		return
+	}
+	rec.call(time.Since(started), w.s.batchSize-len(res.Failures), "")
+	for range res.Failures {
+		rec.failure(errItemFailed)
+	}
+}
+
+func (w *grpcWorker) sendBatch(ctx context.Context) (*pb.BatchSendResponse, error) {
+	stream, err := w.client.Service().BatchSendMessages(ctx)
+	if err != nil {
+		return nil, err
+	}
+	req := w.request()
+	for i := 0; i < w.s.batchSize; i++ {
+		if err := stream.Send(req); err != nil {
+			if err == io.EOF {
+				// Сервер завершил вызов; ошибка возвращается из CloseAndRecv
+				break
+			}
+			return nil, err
+		}
+	}
+	return stream.CloseAndRecv()
+}
+
+// stream отправляет сообщения в один поток SendMessageStream, задержка сообщения - время от отправки
+// до получения ack с тем же correlation_id. Возвращает false, если разрешения закончились.
+func (w *grpcWorker) stream(ctx context.Context, p *pacer, rec *recorder) bool {
+	streamCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	stream, err := w.client.Service().SendMessageStream(streamCtx)
+	if err != nil {
+		rec.failure(grpcCode(err))
+		// Пауза перед повторным открытием, чтобы не нагружать недоступный сервер
+		select {
+		case <-time.After(streamReopenDelay):
+		case <-ctx.Done():
+		}
+		return true
+	}
+
+	var mu sync.Mutex
+	pending := make(map[string]time.Time)
+	streamErr := "" // Код ошибки потока, полученной Recv; читается после завершения её горутины
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for {
+			ack, err := stream.Recv()
+			if err != nil {
+				if ctx.Err() != nil || err == io.EOF {
+					return
+				}
+				// Сообщения без ack учитываются после завершения отправки с кодом ошибки потока
+				streamErr = grpcCode(err)
+				cancel()
+				return
+			}
+			mu.Lock()
+			sent, ok := pending[ack.CorrelationId]
+			delete(pending, ack.CorrelationId)
+			mu.Unlock()
+			if !ok {
+				continue
+			}
+			if ack.Status == "failed" {
+				rec.call(time.Since(sent), 0, errAckFailed)
+			} else {
+				rec.call(time.Since(sent), 1, "")
+			}
+		}
+	}()
+
+	more := true
+	for seq := 0; ; seq++ {
+		if more = p.next(streamCtx); !more {
+			break
+		}
+		id := strconv.Itoa(seq)
+		mu.Lock()
+		pending[id] = time.Now()
+		mu.Unlock()
+		err := stream.Send(&pb.StreamMessageRequest{CorrelationId: id, Content: w.s.payload, Topic: w.s.topic, Priority: w.s.priority})
+		if err != nil {
+			// Ошибка потока будет получена из Recv
+			break
+		}
+	}
+	if ctx.Err() == nil {
+		// Разрешения закончились (-n): дожидаемся оставшихся ack
+		stream.CloseSend()
+	}
+	<-done
+	if ctx.Err() == nil {
+		// Сообщения, отправленные после ошибки потока или оставшиеся без ack при его завершении
+		// сервером, считаются неудачными; при окончании нагрузки они не учитываются, как и вызовы unary
+		code := streamErr
+		if code == "" {
+			code = errNoAck
+		}
+		for _, sent := range pending {
+			rec.call(time.Since(sent), 0, code)
+		}
+	}
+	// Поток открывается заново, если он завершился с ошибкой, а не закончились разрешения
+	return ctx.Err() == nil && (more || streamCtx.Err() != nil)
+}
+
+// httpTarget воркер режима http; один на всех, клиент HTTP безопасен для использования из нескольких горутин.
+type httpTarget struct {
+	s      *settings
+	url    string
+	body   []byte
+	client *http.Client
+}
+
+func newHTTPTarget(s *settings, useTLS bool, caFile, certFile, keyFile, serverName string) (*httpTarget, error) {
+	body, err := protojson.Marshal(&pb.MessageRequest{Content: s.payload, Topic: s.topic, Priority: s.priority})
+	if err != nil {
+		return nil, err
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.MaxIdleConnsPerHost = s.concurrency
+	if useTLS {
+		tlsConfig, err := tlsutil.ClientConfig(caFile, certFile, keyFile, serverName)
+		if err != nil {
+			return nil, fmt.Errorf("ошибка настройки TLS: %v", err)
+		}
+		transport.TLSClientConfig = tlsConfig
+	}
+	return &httpTarget{
+		s:      s,
+		url:    strings.TrimSuffix(s.url, "/") + "/api/messages",
+		body:   body,
+		client: &http.Client{Transport: transport, Timeout: s.timeout},
+	}, nil
+}
+
+func (h *httpTarget) run(ctx context.Context, p *pacer, rec *recorder) {
+	for p.next(ctx) {
+		started := time.Now()
+		code := h.send(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		sent := 0
+		if code == "" {
+			sent = 1
+		}
+		rec.call(time.Since(started), sent, code)
+	}
+}
+
+// send отправляет сообщение и возвращает ключ ошибки: "HTTP <код>" для ответов не 2xx,
+// "timeout" и "transport" для истечения тайм-аута и сетевых ошибок, пусто при успехе.
+func (h *httpTarget) send(ctx context.Context) string {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, h.url, bytes.NewReader(h.body))
+	if err != nil {
+		return "request"
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if h.s.token != "" {
+		req.Header.Set("Authorization", "Bearer "+h.s.token)
+	}
+	if h.s.apiKey != "" {
+		req.Header.Set("X-API-Key", h.s.apiKey)
+	}
+	res, err := h.client.Do(req)
+	if err != nil {
+		if os.IsTimeout(err) {
+			return "timeout"
+		}
+		return "transport"
+	}
+	// Тело читается полностью, чтобы соединение вернулось в пул
+	io.Copy(io.Discard, res.Body)
+	res.Body.Close()
+	if res.StatusCode/100 != 2 {
+		return "HTTP " + strconv.Itoa(res.StatusCode)
+	}
+	return ""
+}
+
+// grpcCode возвращает ключ ошибки вызова: название gRPC-кода, пусто при успехе.
+func grpcCode(err error) string {
+	if err == nil {
+		return ""
+	}
+	return status.Code(err).String()
+}
@@ -0,0 +1,449 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	pb "go_micro_gRPS/proto/go_micro_gRPC/proto"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// maxLineSize максимальная длина строки NDJSON-файла
+const maxLineSize = 16 << 20
+
+// runSend отправляет одно сообщение из аргументов или пакет сообщений из NDJSON-файла.
+func runSend(ctx context.Context, a *app, args []string) error {
+	fs := flag.NewFlagSet("send", flag.ExitOnError)
+	fs.Usage = commandUsage(fs, "send [флаги] <содержимое>\n       msgctl send -file <файл.ndjson|->")
+	file := fs.String("file", "", "NDJSON-файл: по одному MessageRequest в JSON на строку (- для stdin)")
+	batchSize := fs.Int("batch-size", 500, "количество сообщений файла в одном вызове BatchSendMessages")
+	key := fs.String("key", "", "ключ записи Kafka")
+	topic := fs.String("topic", "", "топик (пусто - топик по умолчанию)")
+	priority := fs.String("priority", "", "приоритет: high, normal, low")
+	contentType := fs.String("content-type", "", "MIME-тип содержимого")
+	delay := fs.Duration("delay", 0, "отложенная отправка через указанное время")
+	ttl := fs.Duration("ttl", 0, "срок жизни сообщения")
+	attributes := attributesFlag{}
+	fs.Var(attributes, "attr", "атрибут name=value (можно указать несколько раз)")
+	fs.Parse(args)
+
+	if *file != "" {
+		if fs.NArg() > 0 {
+			return errors.New("с -file содержимое сообщения в аргументах не указывается")
+		}
+		return sendFile(ctx, a, *file, *batchSize)
+	}
+
+	content := strings.Join(fs.Args(), " ")
+	if content == "" {
+		fs.Usage()
+		return errors.New("не указано содержимое сообщения")
+	}
+	req := &pb.MessageRequest{
+		Content:     content,
+		Key:         *key,
+		Topic:       *topic,
+		Priority:    *priority,
+		ContentType: *contentType,
+	}
+	if len(attributes) > 0 {
+		req.Attributes = attributes
+	}
+	if *delay > 0 {
+		req.Schedule = &pb.MessageRequest_Delay{Delay: durationpb.New(*delay)}
+	}
+	if *ttl > 0 {
+		req.Ttl = durationpb.New(*ttl)
+	}
+
+	res, err := a.client.Send(ctx, req)
+	if err != nil {
+		return err
+	}
+	return a.out.emit(res, []proto.Message{res}, func(t *tabwriter.Writer) {
+		row(t, "ID", "STATUS")
+		row(t, res.Id, res.Status)
+	})
+}
+
+// sendFile отправляет сообщения NDJSON-файла пакетами по batchSize и выводит итог по всем пакетам.
+func sendFile(ctx context.Context, a *app, path string, batchSize int) error {
+	if batchSize <= 0 {
+		return errors.New("-batch-size должен быть положительным")
+	}
+
+	var r io.Reader = os.Stdin
+	if path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		r = f
+	}
+
+	requests, err := readRequests(r)
+	if err != nil {
+		return err
+	}
+
+	result := &pb.BatchSendResponse{}
+	for start := 0; start < len(requests); start += batchSize {
+		end := min(start+batchSize, len(requests))
+		res, err := a.client.SendBatch(ctx, requests[start:end])
+		if err != nil {
+			return fmt.Errorf("пакет сообщений %d-%d: %w", start+1, end, err)
+		}
+		result.Ids = append(result.Ids, res.Ids...)
+		for _, failure := range res.Failures {
+			failure.Index += int32(start)
+			result.Failures = append(result.Failures, failure)
+		}
+	}
+
+	items := make([]proto.Message, len(result.Ids))
+	failures := make(map[int32]*pb.BatchItemFailure, len(result.Failures))
+	for _, failure := range result.Failures {
+		failures[failure.Index] = failure
+	}
+	for i, id := range result.Ids {
+		item := &pb.BatchItemFailure{Index: int32(i), Id: id}
+		if failure, ok := failures[int32(i)]; ok {
+			item = failure
+		}
+		items[i] = item
+	}
+
+	if err := a.out.emit(result, items, func(t *tabwriter.Writer) {
+		row(t, "#", "ID", "ERROR")
+		for _, item := range items {
+			item := item.(*pb.BatchItemFailure)
+			row(t, item.Index+1, item.Id, cell(item.Error))
+		}
+	}); err != nil {
+		return err
+	}
+	if len(result.Failures) > 0 {
+		return fmt.Errorf("не отправлено %d из %d сообщений", len(result.Failures), len(requests))
+	}
+	return nil
+}
+
+// readRequests читает MessageRequest из NDJSON; пустые строки пропускаются.
+func readRequests(r io.Reader) ([]*pb.MessageRequest, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), maxLineSize)
+
+	var requests []*pb.MessageRequest
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+		req := &pb.MessageRequest{}
+		if err := protojson.Unmarshal([]byte(text), req); err != nil {
+			return nil, fmt.Errorf("строка %d: %v", line, err)
+		}
+		requests = append(requests, req)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(requests) == 0 {
+		return nil, errors.New("в файле нет сообщений")
+	}
+	return requests, nil
+}
+
+// runGet выводит сообщение по ID.
+func runGet(ctx context.Context, a *app, args []string) error {
+	fs := flag.NewFlagSet("get", flag.ExitOnError)
+	fs.Usage = commandUsage(fs, "get <id>")
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return errors.New("укажите ID сообщения")
+	}
+	id, err := parseID(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	msg, err := a.client.Get(ctx, id)
+	if err != nil {
+		return err
+	}
+	return a.out.emit(msg, []proto.Message{msg}, func(t *tabwriter.Writer) {
+		row(t, "ID", msg.Id)
+		row(t, "STATUS", msg.Status)
+		row(t, "TOPIC", msg.Topic)
+		row(t, "PRIORITY", msg.Priority)
+		row(t, "KEY", cell(msg.Key))
+		row(t, "CONTENT TYPE", cell(msg.ContentType))
+		row(t, "CREATED", timeCell(msg.CreatedAt))
+		row(t, "CREATED BY", cell(msg.CreatedBy))
+		row(t, "DELIVER AT", timeCell(msg.DeliverAt))
+		row(t, "EXPIRES AT", timeCell(msg.ExpiresAt))
+		names := make([]string, 0, len(msg.Attributes))
+		for name := range msg.Attributes {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			row(t, "ATTR "+name, cell(msg.Attributes[name]))
+		}
+		row(t, "CONTENT", msg.Content)
+	})
+}
+
+// runList выводит страницу сообщений или, с -all, все подходящие сообщения.
+func runList(ctx context.Context, a *app, args []string) error {
+	fs := flag.NewFlagSet("list", flag.ExitOnError)
+	fs.Usage = commandUsage(fs, "list [флаги]")
+	statuses := fs.String("status", "", "статусы через запятую (pending, scheduled, processed, failed, expired)")
+	topics := fs.String("topic", "", "топики через запятую")
+	priorities := fs.String("priority", "", "приоритеты через запятую (high, normal, low)")
+	since := fs.Duration("since", 0, "только сообщения, созданные за указанное время")
+	limit := fs.Int("limit", 50, "размер страницы")
+	pageToken := fs.String("page-token", "", "токен страницы из предыдущего вывода")
+	all := fs.Bool("all", false, "выгрузить все страницы")
+	fs.Parse(args)
+
+	req := &pb.ListMessagesRequest{
+		Statuses:   splitList(*statuses),
+		Topics:     splitList(*topics),
+		Priorities: splitList(*priorities),
+		PageSize:   int32(*limit),
+		PageToken:  *pageToken,
+	}
+	if *since > 0 {
+		req.CreatedAfter = timestamppb.New(time.Now().Add(-*since))
+	}
+
+	result := &pb.ListMessagesResponse{}
+	for {
+		res, err := a.client.List(ctx, req)
+		if err != nil {
+			return err
+		}
+		result.Messages = append(result.Messages, res.Messages...)
+		result.NextPageToken = res.NextPageToken
+		if !*all || res.NextPageToken == "" {
+			break
+		}
+		req.PageToken = res.NextPageToken
+	}
+
+	items := make([]proto.Message, len(result.Messages))
+	for i, msg := range result.Messages {
+		items[i] = msg
+	}
+	err := a.out.emit(result, items, func(t *tabwriter.Writer) {
+		row(t, "ID", "STATUS", "PRIORITY", "TOPIC", "CREATED", "KEY", "CONTENT")
+		for _, msg := range result.Messages {
+			row(t, msg.Id, msg.Status, msg.Priority, msg.Topic, timeCell(msg.CreatedAt), cell(msg.Key), cell(msg.Content))
+		}
+	})
+	if err == nil && result.NextPageToken != "" && a.out.format != formatJSON {
+		fmt.Fprintf(os.Stderr, "Следующая страница: msgctl list -page-token %s\n", result.NextPageToken)
+	}
+	return err
+}
+
+// runStats выводит статистику сообщений.
+func runStats(ctx context.Context, a *app, args []string) error {
+	fs := flag.NewFlagSet("stats", flag.ExitOnError)
+	fs.Usage = commandUsage(fs, "stats [флаги]")
+	topic := fs.String("topic", "", "статистика только по топику")
+	fs.Parse(args)
+
+	stats, err := a.client.Stats(ctx, *topic)
+	if err != nil {
+		return err
+	}
+	return a.out.emit(stats, []proto.Message{stats}, func(t *tabwriter.Writer) {
+		row(t, "STATUS", "COUNT")
+		for _, status := range sortedKeys(stats.StatusCounts) {
+			row(t, status, stats.StatusCounts[status])
+		}
+		row(t, "total", stats.TotalCount)
+		row(t)
+		row(t, "PRIORITY", "COUNT")
+		for _, priority := range []string{"high", "normal", "low"} {
+			row(t, priority, stats.PriorityCounts[priority])
+		}
+		row(t)
+		row(t, "WINDOW", "INGESTED", "PROCESSED", "INGEST/S", "PROCESSED/S")
+		for _, w := range stats.Throughput {
+			row(t, w.Window, w.Ingested, w.Processed, fmt.Sprintf("%.2f", w.IngestRate), fmt.Sprintf("%.2f", w.ProcessingRate))
+		}
+		if l := stats.Latency; l != nil {
+			row(t)
+			row(t, "LATENCY "+l.Window, "COUNT", "AVG", "P50", "P90", "P95", "P99", "MAX")
+			row(t, "", l.Count, ms(l.AvgMs), ms(l.P50Ms), ms(l.P90Ms), ms(l.P95Ms), ms(l.P99Ms), ms(l.MaxMs))
+		}
+	})
+}
+
+// runTail выводит сообщения, прочитанные consumer'ом сервиса, до прерывания (Ctrl+C).
+func runTail(ctx context.Context, a *app, args []string) error {
+	fs := flag.NewFlagSet("tail", flag.ExitOnError)
+	fs.Usage = commandUsage(fs, "tail [флаги]")
+	fromOffset := fs.Int64("from-offset", -1, "сначала вывести сообщения из истории consumer'а с этого смещения партиции")
+	fromTopic := fs.String("from-topic", "", "топик Kafka смещения -from-offset (обязателен с -from-offset)")
+	fromPartition := fs.Int("from-partition", 0, "партиция смещения -from-offset")
+	bufferSize := fs.Int("buffer", 0, "размер буфера подписки (0 - по умолчанию, не больше 10000)")
+	fs.Parse(args)
+
+	req := &pb.SubscribeRequest{BufferSize: int32(*bufferSize)}
+	if *fromOffset >= 0 {
+		req.StartOffset = fromOffset
+		req.StartTopic = *fromTopic
+		req.StartPartition = int32(*fromPartition)
+	}
+	stream, err := a.client.Subscribe(ctx, req)
+	if err != nil {
+		return err
+	}
+
+	if a.out.format == formatTable {
+		fmt.Fprintf(a.out.w, "%-19s  %-24s  %-8s  %-16s  %s\n", "TIME", "TOPIC/PARTITION@OFFSET", "PRIORITY", "KEY", "VALUE")
+	}
+	for {
+		msg, err := stream.Recv()
+		if err != nil {
+			if ctx.Err() != nil || err == io.EOF {
+				return nil
+			}
+			return err
+		}
+		if a.out.format != formatTable {
+			if err := a.out.writeJSON(msg); err != nil {
+				return err
+			}
+			continue
+		}
+		// Таблица выводится построчно с фиксированной шириной колонок, без буферизации
+		position := fmt.Sprintf("%s/%d@%d", msg.Topic, msg.Partition, msg.Offset)
+		fmt.Fprintf(a.out.w, "%-19s  %-24s  %-8s  %-16s  %s\n",
+			timeCell(msg.Time), position, msg.Priority, cell(msg.Key), cell(msg.Value))
+	}
+}
+
+// runRetry повторно отправляет сообщения со статусом failed.
+func runRetry(ctx context.Context, a *app, args []string) error {
+	fs := flag.NewFlagSet("retry", flag.ExitOnError)
+	fs.Usage = commandUsage(fs, "retry <id> [<id>...]")
+	fs.Parse(args)
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return errors.New("укажите ID сообщений")
+	}
+	ids := make([]int32, fs.NArg())
+	for i, arg := range fs.Args() {
+		id, err := parseID(arg)
+		if err != nil {
+			return err
+		}
+		ids[i] = id
+	}
+
+	var items []proto.Message
+	failed := 0
+	for _, id := range ids {
+		msg, err := a.client.Retry(ctx, id)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Сообщение %d: %s\n", id, errorText(err))
+			failed++
+			continue
+		}
+		items = append(items, msg)
+	}
+
+	if err := a.out.emit(nil, items, func(t *tabwriter.Writer) {
+		row(t, "ID", "STATUS", "TOPIC", "PRIORITY")
+		for _, item := range items {
+			msg := item.(*pb.Message)
+			row(t, msg.Id, msg.Status, msg.Topic, msg.Priority)
+		}
+	}); err != nil {
+		return err
+	}
+	if failed > 0 {
+		return fmt.Errorf("не удалось повторить %d из %d сообщений", failed, len(ids))
+	}
+	return nil
+}
+
+// attributesFlag флаг -attr name=value, накапливающий атрибуты сообщения.
+type attributesFlag map[string]string
+
+func (f attributesFlag) String() string {
+	pairs := make([]string, 0, len(f))
+	for _, name := range sortedKeys(f) {
+		pairs = append(pairs, name+"="+f[name])
+	}
+	return strings.Join(pairs, ",")
+}
+
+func (f attributesFlag) Set(value string) error {
+	name, v, ok := strings.Cut(value, "=")
+	if !ok || name == "" {
+		return fmt.Errorf("атрибут должен быть в формате name=value: %q", value)
+	}
+	f[name] = v
+	return nil
+}
+
+func commandUsage(fs *flag.FlagSet, synopsis string) func() {
+	return func() {
+		fmt.Fprintf(os.Stderr, "Использование: msgctl %s\n", synopsis)
+		fs.PrintDefaults()
+	}
+}
+
+func parseID(s string) (int32, error) {
+	id, err := strconv.ParseInt(s, 10, 32)
+	if err != nil || id <= 0 {
+		return 0, fmt.Errorf("некорректный ID сообщения %q", s)
+	}
+	return int32(id), nil
+}
+
+// splitList разбивает значение флага по запятым, пропуская пустые элементы.
+func splitList(s string) []string {
+	var values []string
+	for _, v := range strings.Split(s, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			values = append(values, v)
+		}
+	}
+	return values
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func ms(v float64) string {
+	return fmt.Sprintf("%.1fms", v)
+}
@@ -0,0 +1,153 @@
+// msgctl утилита командной строки для работы с MessageService по gRPC.
+//
+//	msgctl [глобальные флаги] <команда> [флаги команды] [аргументы]
+//
+// Примеры:
+//
+//	msgctl send -key order-42 -priority high "Hello, World!"
+//	msgctl send -file messages.ndjson
+//	msgctl -o json get 42
+//	msgctl list -status failed -since 1h
+//	msgctl stats -topic orders
+//	msgctl -o ndjson tail
+//	msgctl retry 42 43
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"go_micro_gRPS/client"
+	"go_micro_gRPS/internal/tlsutil"
+
+	"google.golang.org/grpc/status"
+)
+
+// app общие для команд клиент и формат вывода.
+type app struct {
+	client *client.Client
+	out    *printer
+}
+
+// command подкоманда msgctl.
+type command struct {
+	name    string
+	summary string
+	run     func(ctx context.Context, a *app, args []string) error
+}
+
+var commands = []command{
+	{"send", "отправить сообщение или NDJSON-файл сообщений (-file)", runSend},
+	{"get", "показать сообщение по ID", runGet},
+	{"list", "список сообщений с фильтрами", runList},
+	{"stats", "статистика сообщений", runStats},
+	{"tail", "следить за сообщениями, прочитанными из Kafka", runTail},
+	{"retry", "повторно отправить сообщения со статусом failed", runRetry},
+}
+
+func main() {
+	global := flag.NewFlagSet("msgctl", flag.ExitOnError)
+	addr := global.String("addr", envOr("MSGCTL_ADDR", client.DefaultAddress), "адрес gRPC сервера, dns:///host:port или список адресов через запятую (MSGCTL_ADDR)")
+	balancer := global.String("lb", string(client.RoundRobin), "политика балансировки: round_robin, least_request_experimental, pick_first")
+	token := global.String("token", os.Getenv("MSGCTL_TOKEN"), "JWT для аутентификации (MSGCTL_TOKEN)")
+	apiKey := global.String("api-key", os.Getenv("MSGCTL_API_KEY"), "API-ключ для аутентификации (MSGCTL_API_KEY)")
+	useTLS := global.Bool("tls", false, "подключаться по TLS")
+	caFile := global.String("ca", "", "CA для проверки сертификата сервера (по умолчанию системный)")
+	certFile := global.String("cert", "", "клиентский сертификат для mTLS")
+	keyFile := global.String("key", "", "ключ клиентского сертификата для mTLS")
+	serverName := global.String("server-name", "", "имя сервера для проверки сертификата (по умолчанию из адреса)")
+	format := global.String("o", formatTable, "формат вывода: table, json, ndjson")
+	timeout := global.Duration("timeout", 10*time.Second, "тайм-аут вызова (кроме tail)")
+	global.Usage = func() { usage(global) }
+	global.Parse(os.Args[1:])
+
+	if global.NArg() == 0 {
+		usage(global)
+		os.Exit(2)
+	}
+	cmd, ok := findCommand(global.Arg(0))
+	if !ok {
+		fmt.Fprintf(os.Stderr, "msgctl: неизвестная команда %q\n\n", global.Arg(0))
+		usage(global)
+		os.Exit(2)
+	}
+
+	out, err := newPrinter(*format, os.Stdout)
+	if err != nil {
+		fatal(err)
+	}
+
+	opts := []client.Option{
+		client.WithAddresses(splitList(*addr)...),
+		client.WithBalancer(client.Balancer(*balancer)),
+		client.WithBearerToken(*token),
+		client.WithAPIKey(*apiKey),
+		client.WithTimeout(*timeout),
+	}
+	if *useTLS {
+		tlsConfig, err := tlsutil.ClientConfig(*caFile, *certFile, *keyFile, *serverName)
+		if err != nil {
+			fatal(fmt.Errorf("ошибка настройки TLS: %v", err))
+		}
+		opts = append(opts, client.WithTLS(tlsConfig))
+	}
+	c, err := client.New(opts...)
+	if err != nil {
+		fatal(err)
+	}
+	defer c.Close()
+
+	// Ctrl+C прерывает текущий вызов (и завершает tail)
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if err := cmd.run(ctx, &app{client: c, out: out}, global.Args()[1:]); err != nil {
+		stop()
+		c.Close()
+		fatal(err)
+	}
+}
+
+func findCommand(name string) (command, bool) {
+	for _, cmd := range commands {
+		if cmd.name == name {
+			return cmd, true
+		}
+	}
+	return command{}, false
+}
+
+func usage(global *flag.FlagSet) {
+	fmt.Fprintf(os.Stderr, "Использование: msgctl [глобальные флаги] <команда> [флаги команды] [аргументы]\n\nКоманды:\n")
+	for _, cmd := range commands {
+		fmt.Fprintf(os.Stderr, "  %-6s %s\n", cmd.name, cmd.summary)
+	}
+	fmt.Fprintf(os.Stderr, "\nФлаги команды: msgctl <команда> -h\n\nГлобальные флаги:\n")
+	global.PrintDefaults()
+}
+
+// fatal выводит ошибку и завершает программу с кодом 1.
+func fatal(err error) {
+	fmt.Fprintf(os.Stderr, "msgctl: %s\n", errorText(err))
+	os.Exit(1)
+}
+
+// errorText форматирует ошибку; для gRPC-ошибок - код и сообщение сервера.
+func errorText(err error) string {
+	if st, ok := status.FromError(err); ok {
+		return st.Code().String() + ": " + st.Message()
+	}
+	return err.Error()
+}
+
+func envOr(key, defaultValue string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return defaultValue
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// Форматы вывода
+const (
+	formatTable  = "table"  // Таблица для чтения человеком
+	formatJSON   = "json"   // Один JSON-документ с отступами
+	formatNDJSON = "ndjson" // Один JSON-объект на строку для обработки скриптами
+)
+
+// maxCellWidth максимальная ширина текстовой ячейки таблицы; длинные значения обрезаются
+const maxCellWidth = 48
+
+// printer выводит результаты команд в выбранном формате.
+type printer struct {
+	format string
+	w      io.Writer
+}
+
+func newPrinter(format string, w io.Writer) (*printer, error) {
+	switch format {
+	case formatTable, formatJSON, formatNDJSON:
+		return &printer{format: format, w: w}, nil
+	default:
+		return nil, fmt.Errorf("неизвестный формат вывода %q: допустимы table, json, ndjson", format)
+	}
+}
+
+// emit выводит результат команды: json - документ whole (если whole nil - каждый из items),
+// ndjson - каждый из items на отдельной строке, table - таблицу, заполняемую функцией table.
+func (p *printer) emit(whole proto.Message, items []proto.Message, table func(t *tabwriter.Writer)) error {
+	switch p.format {
+	case formatJSON:
+		if whole != nil {
+			return p.writeJSON(whole)
+		}
+		for _, item := range items {
+			if err := p.writeJSON(item); err != nil {
+				return err
+			}
+		}
+		return nil
+	case formatNDJSON:
+		for _, item := range items {
+			if err := p.writeJSON(item); err != nil {
+				return err
+			}
+		}
+		return nil
+	default:
+		t := tabwriter.NewWriter(p.w, 0, 4, 2, ' ', 0)
+		table(t)
+		return t.Flush()
+	}
+}
+
+// writeJSON выводит сообщение в JSON с именами полей из proto: с отступами для json, одной строкой для ndjson.
+func (p *printer) writeJSON(m proto.Message) error {
+	data, err := protojson.MarshalOptions{UseProtoNames: true}.Marshal(m)
+	if err != nil {
+		return err
+	}
+	// protojson не гарантирует стабильных пробелов, поэтому вывод нормализуется
+	var buf bytes.Buffer
+	if p.format == formatJSON {
+		err = json.Indent(&buf, data, "", "  ")
+	} else {
+		err = json.Compact(&buf, data)
+	}
+	if err != nil {
+		return err
+	}
+	buf.WriteByte('\n')
+	_, err = p.w.Write(buf.Bytes())
+	return err
+}
+
+// row выводит строку таблицы, разделяя значения табуляцией.
+func row(t io.Writer, values ...interface{}) {
+	cells := make([]string, len(values))
+	for i, v := range values {
+		cells[i] = fmt.Sprint(v)
+	}
+	fmt.Fprintln(t, strings.Join(cells, "\t"))
+}
+
+// cell готовит текст для ячейки таблицы: переводы строк заменяются пробелами, длинный текст обрезается.
+func cell(s string) string {
+	s = strings.Join(strings.Fields(s), " ")
+	if s == "" {
+		return "-"
+	}
+	if runes := []rune(s); len(runes) > maxCellWidth {
+		return string(runes[:maxCellWidth-1]) + "…"
+	}
+	return s
+}
+
+// timeCell форматирует время для таблицы в локальной зоне.
+func timeCell(ts *timestamppb.Timestamp) string {
+	if ts == nil {
+		return "-"
+	}
+	return ts.AsTime().Local().Format(time.DateTime)
+}
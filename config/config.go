@@ -5,6 +5,9 @@ import (
 	"github.com/joho/godotenv"
 	"log"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 )
 
 type Config struct {
@@ -16,7 +19,60 @@ type Config struct {
 	//PostgresPort          string
 	ConnStr      string
 	KafkaBrokers string
-	KafkaTopic   string
+	KafkaTopic   string // Топик по умолчанию для сообщений без явно указанного топика
+
+	KafkaTopics         []string // Топики, в которые разрешена отправка; всегда включают KafkaTopic
+	KafkaConsumerTopics []string // Топики, на которые подписан consumer (по умолчанию - все KafkaTopics)
+
+	// Параметры топиков (и их полос приоритета), создаваемых при запуске; существующие топики не меняются
+	KafkaTopicPartitions        int // Количество партиций
+	KafkaTopicReplicationFactor int // Фактор репликации, не больше количества брокеров
+
+	// Kafka producer: пакетная отправка и выбор партиции
+	KafkaProducerAsync        bool          // Асинхронная отправка SendMessage с обновлением статуса по подтверждению Kafka
+	KafkaProducerBatchSize    int           // Максимальное количество записей в пакете
+	KafkaProducerBatchTimeout time.Duration // Максимальное время накопления неполного пакета
+	KafkaProducerCompression  string        // Сжатие пакетов: none, gzip, snappy, lz4, zstd
+	KafkaKeyStrategy          string        // Ключ записи: client (по умолчанию), id, random, attribute:<имя>
+	KafkaBalancer             string        // Выбор партиции: hash (по умолчанию), murmur2, crc32, round_robin, least_bytes
+
+	// Пул соединений PostgreSQL (0 - без ограничения)
+	DBMaxOpenConns    int           // Максимальное количество открытых соединений
+	DBMaxIdleConns    int           // Максимальное количество простаивающих соединений
+	DBConnMaxLifetime time.Duration // Максимальное время жизни соединения
+	DBConnMaxIdleTime time.Duration // Максимальное время простоя соединения
+
+	GRPCAccessLog bool // Журналирование каждого gRPC-вызова
+
+	// TLS gRPC-сервера. Если GRPCTLSCert не задан, сервер работает без шифрования.
+	GRPCTLSCert           string        // Путь к сертификату сервера (PEM)
+	GRPCTLSKey            string        // Путь к ключу сервера (PEM)
+	GRPCTLSClientCA       string        // CA для проверки клиентских сертификатов (mTLS)
+	GRPCTLSClientAuth     string        // Режим проверки клиентов: none, request, verify-if-given, require
+	GRPCTLSReloadInterval time.Duration // Период проверки изменения файлов сертификатов (0 и меньше - без перезагрузки)
+
+	// Аутентификация и авторизация gRPC и HTTP API
+	AuthEnabled       bool
+	AuthAPIKeysFile   string // JSON-файл со статическими API-ключами
+	AuthJWTHMACSecret string // Секрет для JWT HS256
+	AuthJWKSFile      string // Локальный JWKS-файл с ключами для JWT RS256
+	AuthJWTIssuer     string // Ожидаемый iss токена
+	AuthJWTAudience   string // Ожидаемый aud токена
+	AuthJWTRolesClaim string // Claim со списком ролей (по умолчанию "roles")
+	AuthPolicyFile    string // JSON-файл с ролями для методов и маршрутов (дополняет политику по умолчанию)
+
+	// Ограничение частоты отправки сообщений по клиентам (token bucket)
+	RateLimitEnabled     bool
+	RateLimitRPS         float64 // Скорость пополнения ведра по умолчанию, запросов в секунду
+	RateLimitBurst       int     // Ёмкость ведра по умолчанию
+	RateLimitClientsFile string  // JSON-файл с индивидуальными лимитами клиентов
+
+	IdempotencyRetention time.Duration // Время хранения ключей идемпотентности отправки
+
+	// Планировщик отложенных сообщений и истечения срока жизни
+	SchedulerEnabled   bool
+	SchedulerInterval  time.Duration // Период опроса БД, больше нуля
+	SchedulerBatchSize int           // Максимальное количество сообщений, отправляемых за один запрос
 }
 
 func LoadConfig() Config {
@@ -25,7 +81,7 @@ func LoadConfig() Config {
 		log.Fatalf("Error loading .env file")
 	}
 
-	return Config{
+	cfg := Config{
 		//KafkaBootstrapServers: os.Getenv("KAFKA_BOOTSTRAP_SERVERS"),
 		//PostgresUser:          os.Getenv("POSTGRES_USER"),
 		//PostgresPassword:      os.Getenv("POSTGRES_PASSWORD"),
@@ -35,5 +91,156 @@ func LoadConfig() Config {
 		ConnStr:      os.Getenv("DB_CONN_STR"),
 		KafkaBrokers: os.Getenv("KAFKA_BROKERS"),
 		KafkaTopic:   os.Getenv("KAFKA_TOPIC"),
+
+		KafkaTopics:         getEnvList("KAFKA_TOPICS"),
+		KafkaConsumerTopics: getEnvList("KAFKA_CONSUMER_TOPICS"),
+
+		KafkaTopicPartitions:        getEnvPositiveInt("KAFKA_TOPIC_PARTITIONS", 1),
+		KafkaTopicReplicationFactor: getEnvPositiveInt("KAFKA_TOPIC_REPLICATION_FACTOR", 1),
+
+		KafkaProducerAsync:        getEnvBool("KAFKA_PRODUCER_ASYNC", false),
+		KafkaProducerBatchSize:    getEnvInt("KAFKA_PRODUCER_BATCH_SIZE", 100),
+		KafkaProducerBatchTimeout: getEnvDuration("KAFKA_PRODUCER_BATCH_TIMEOUT", 10*time.Millisecond),
+		KafkaProducerCompression:  os.Getenv("KAFKA_PRODUCER_COMPRESSION"),
+		KafkaKeyStrategy:          os.Getenv("KAFKA_KEY_STRATEGY"),
+		KafkaBalancer:             os.Getenv("KAFKA_BALANCER"),
+
+		DBMaxOpenConns:    getEnvInt("DB_MAX_OPEN_CONNS", 25),
+		DBMaxIdleConns:    getEnvInt("DB_MAX_IDLE_CONNS", 25),
+		DBConnMaxLifetime: getEnvDuration("DB_CONN_MAX_LIFETIME", 30*time.Minute),
+		DBConnMaxIdleTime: getEnvDuration("DB_CONN_MAX_IDLE_TIME", 5*time.Minute),
+
+		GRPCAccessLog: getEnvBool("GRPC_ACCESS_LOG", true),
+
+		GRPCTLSCert:           os.Getenv("GRPC_TLS_CERT"),
+		GRPCTLSKey:            os.Getenv("GRPC_TLS_KEY"),
+		GRPCTLSClientCA:       os.Getenv("GRPC_TLS_CLIENT_CA"),
+		GRPCTLSClientAuth:     os.Getenv("GRPC_TLS_CLIENT_AUTH"),
+		GRPCTLSReloadInterval: getEnvDuration("GRPC_TLS_RELOAD_INTERVAL", 30*time.Second),
+
+		AuthEnabled:       getEnvBool("AUTH_ENABLED", false),
+		AuthAPIKeysFile:   os.Getenv("AUTH_API_KEYS_FILE"),
+		AuthJWTHMACSecret: os.Getenv("AUTH_JWT_HMAC_SECRET"),
+		AuthJWKSFile:      os.Getenv("AUTH_JWKS_FILE"),
+		AuthJWTIssuer:     os.Getenv("AUTH_JWT_ISSUER"),
+		AuthJWTAudience:   os.Getenv("AUTH_JWT_AUDIENCE"),
+		AuthJWTRolesClaim: os.Getenv("AUTH_JWT_ROLES_CLAIM"),
+		AuthPolicyFile:    os.Getenv("AUTH_POLICY_FILE"),
+
+		RateLimitEnabled:     getEnvBool("RATE_LIMIT_ENABLED", false),
+		RateLimitRPS:         getEnvFloat("RATE_LIMIT_RPS", 100),
+		RateLimitBurst:       getEnvInt("RATE_LIMIT_BURST", 200),
+		RateLimitClientsFile: os.Getenv("RATE_LIMIT_CLIENTS_FILE"),
+
+		IdempotencyRetention: getEnvPositiveDuration("IDEMPOTENCY_RETENTION", 24*time.Hour),
+
+		SchedulerEnabled:   getEnvBool("SCHEDULER_ENABLED", true),
+		SchedulerInterval:  getEnvPositiveDuration("SCHEDULER_INTERVAL", time.Second),
+		SchedulerBatchSize: getEnvPositiveInt("SCHEDULER_BATCH_SIZE", 100),
+	}
+
+	// Топик по умолчанию разрешен всегда
+	if !contains(cfg.KafkaTopics, cfg.KafkaTopic) {
+		cfg.KafkaTopics = append([]string{cfg.KafkaTopic}, cfg.KafkaTopics...)
+	}
+	if len(cfg.KafkaConsumerTopics) == 0 {
+		cfg.KafkaConsumerTopics = cfg.KafkaTopics
+	}
+	return cfg
+}
+
+// getEnvList возвращает непустые значения переменной окружения, разделенные запятыми
+func getEnvList(key string) []string {
+	var values []string
+	for _, value := range strings.Split(os.Getenv(key), ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// getEnvInt возвращает целое значение переменной окружения или defaultValue
+func getEnvInt(key string, defaultValue int) int {
+	value, ok := os.LookupEnv(key)
+	if !ok || value == "" {
+		return defaultValue
+	}
+	parsed, err := strconv.Atoi(value)
+	if err != nil {
+		log.Printf("Некорректное значение %s=%q, используется %v", key, value, defaultValue)
+		return defaultValue
+	}
+	return parsed
+}
+
+// getEnvPositiveInt возвращает положительное целое значение переменной окружения или defaultValue
+func getEnvPositiveInt(key string, defaultValue int) int {
+	value := getEnvInt(key, defaultValue)
+	if value < 1 {
+		log.Printf("Значение %s=%d должно быть положительным, используется %v", key, value, defaultValue)
+		return defaultValue
+	}
+	return value
+}
+
+// getEnvFloat возвращает дробное значение переменной окружения или defaultValue
+func getEnvFloat(key string, defaultValue float64) float64 {
+	value, ok := os.LookupEnv(key)
+	if !ok || value == "" {
+		return defaultValue
+	}
+	parsed, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		log.Printf("Некорректное значение %s=%q, используется %v", key, value, defaultValue)
+		return defaultValue
+	}
+	return parsed
+}
+
+// getEnvDuration возвращает длительность из переменной окружения (например, "30s") или defaultValue
+func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
+	value, ok := os.LookupEnv(key)
+	if !ok || value == "" {
+		return defaultValue
+	}
+	parsed, err := time.ParseDuration(value)
+	if err != nil {
+		log.Printf("Некорректное значение %s=%q, используется %v", key, value, defaultValue)
+		return defaultValue
+	}
+	return parsed
+}
+
+// getEnvPositiveDuration возвращает положительную длительность из переменной окружения или defaultValue
+func getEnvPositiveDuration(key string, defaultValue time.Duration) time.Duration {
+	value := getEnvDuration(key, defaultValue)
+	if value <= 0 {
+		log.Printf("Значение %s=%v должно быть положительным, используется %v", key, value, defaultValue)
+		return defaultValue
+	}
+	return value
+}
+
+// getEnvBool возвращает булево значение переменной окружения или defaultValue, если она не задана
+func getEnvBool(key string, defaultValue bool) bool {
+	value, ok := os.LookupEnv(key)
+	if !ok || value == "" {
+		return defaultValue
+	}
+	parsed, err := strconv.ParseBool(value)
+	if err != nil {
+		log.Printf("Некорректное значение %s=%q, используется %v", key, value, defaultValue)
+		return defaultValue
 	}
+	return parsed
 }
@@ -1,157 +1,24 @@
-// Package docs Code generated by swaggo/swag. DO NOT EDIT
+// Package docs Swagger-описание REST API, сгенерированное protoc-gen-openapiv2 из proto/service.proto.
+// Файл service.swagger.json не редактируется вручную: после изменения service.proto
+// он генерируется заново вместе с Go-кодом (см. команду в cmd/api/main.go).
 package docs
 
-import "github.com/swaggo/swag"
+import (
+	_ "embed"
 
-const docTemplate = `{
-    "schemes": {{ marshal .Schemes }},
-    "swagger": "2.0",
-    "info": {
-        "description": "{{escape .Description}}",
-        "title": "{{.Title}}",
-        "contact": {},
-        "version": "{{.Version}}"
-    },
-    "host": "{{.Host}}",
-    "basePath": "{{.BasePath}}",
-    "paths": {
-        "/api/consume": {
-            "get": {
-                "description": "Возвращает сообщения из кафки",
-                "produces": [
-                    "application/json"
-                ],
-                "tags": [
-                    "consumer"
-                ],
-                "summary": "Получение сообщений из кафки",
-                "responses": {
-                    "200": {
-                        "description": "OK",
-                        "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
-                        }
-                    },
-                    "500": {
-                        "description": "Internal Server Error",
-                        "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
-                        }
-                    }
-                }
-            }
-        },
-        "/api/messages": {
-            "post": {
-                "description": "Отправляет сообщение в Kafka и сохраняет его в базе данных через REST API",
-                "consumes": [
-                    "application/json"
-                ],
-                "produces": [
-                    "application/json"
-                ],
-                "tags": [
-                    "messages"
-                ],
-                "summary": "Отправка сообщения через HTTP",
-                "parameters": [
-                    {
-                        "description": "Сообщение",
-                        "name": "message",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/handlers.HTTPMessage"
-                        }
-                    }
-                ],
-                "responses": {
-                    "200": {
-                        "description": "OK",
-                        "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
-                        }
-                    },
-                    "400": {
-                        "description": "Bad Request",
-                        "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
-                        }
-                    }
-                }
-            }
-        },
-        "/api/stats": {
-            "get": {
-                "description": "Возвращает количество обработанных сообщений из базы данных",
-                "produces": [
-                    "application/json"
-                ],
-                "tags": [
-                    "stats"
-                ],
-                "summary": "Получение статистики обработанных сообщений",
-                "responses": {
-                    "200": {
-                        "description": "OK",
-                        "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "integer"
-                            }
-                        }
-                    },
-                    "500": {
-                        "description": "Internal Server Error",
-                        "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
-                        }
-                    }
-                }
-            }
-        }
-    },
-    "definitions": {
-        "handlers.HTTPMessage": {
-            "type": "object",
-            "properties": {
-                "content": {
-                    "type": "string"
-                }
-            }
-        }
-    }
-}`
+	"github.com/swaggo/swag"
+)
 
-// SwaggerInfo holds exported Swagger Info so clients can modify it
-var SwaggerInfo = &swag.Spec{
-	Version:          "",
-	Host:             "",
-	BasePath:         "",
-	Schemes:          []string{},
-	Title:            "",
-	Description:      "",
-	InfoInstanceName: "swagger",
-	SwaggerTemplate:  docTemplate,
-	LeftDelim:        "{{",
-	RightDelim:       "}}",
+//go:embed service.swagger.json
+var serviceSwagger string
+
+// doc отдаёт Swagger UI сгенерированное описание.
+type doc struct{}
+
+func (doc) ReadDoc() string {
+	return serviceSwagger
 }
 
 func init() {
-	swag.Register(SwaggerInfo.InstanceName(), SwaggerInfo)
+	swag.Register(swag.Name, doc{})
 }
@@ -0,0 +1,235 @@
+// Package apperrors единая классификация ошибок сервиса для gRPC и HTTP.
+// Ошибки БД и Kafka не передаются клиенту как есть: клиент получает код,
+// безопасное сообщение и структурированные детали (errdetails).
+package apperrors
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"net"
+	"syscall"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/segmentio/kafka-go"
+)
+
+// Domain домен ошибок в errdetails.ErrorInfo
+const Domain = "go_micro_grpc"
+
+// DefaultRetryDelay рекомендуемая задержка перед повтором запроса при недоступности зависимостей
+const DefaultRetryDelay = time.Second
+
+// Kind класс ошибки, определяющий gRPC-код и HTTP-статус.
+type Kind int
+
+const (
+	KindInternal Kind = iota
+	KindInvalidArgument
+	KindNotFound
+	KindUnavailable
+	KindDeadlineExceeded
+	KindCanceled
+	KindUnauthenticated
+	KindPermissionDenied
+	KindResourceExhausted
+	KindFailedPrecondition
+)
+
+// String возвращает имя класса ошибки, используемое как reason в ErrorInfo и code в HTTP-ответе.
+func (k Kind) String() string {
+	switch k {
+	case KindInvalidArgument:
+		return "INVALID_ARGUMENT"
+	case KindNotFound:
+		return "NOT_FOUND"
+	case KindUnavailable:
+		return "UNAVAILABLE"
+	case KindDeadlineExceeded:
+		return "DEADLINE_EXCEEDED"
+	case KindCanceled:
+		return "CANCELED"
+	case KindUnauthenticated:
+		return "UNAUTHENTICATED"
+	case KindPermissionDenied:
+		return "PERMISSION_DENIED"
+	case KindResourceExhausted:
+		return "RESOURCE_EXHAUSTED"
+	case KindFailedPrecondition:
+		return "FAILED_PRECONDITION"
+	default:
+		return "INTERNAL"
+	}
+}
+
+// Error ошибка с явно заданным классом и сообщением для клиента.
+type Error struct {
+	Kind       Kind
+	Message    string        // Сообщение, которое можно показать клиенту
+	Field      string        // Поле запроса для KindInvalidArgument
+	Dependency string        // Недоступная зависимость для KindUnavailable ("postgres", "kafka")
+	RetryDelay time.Duration // Рекомендуемая задержка перед повтором
+	Err        error         // Исходная ошибка, клиенту не передаётся
+}
+
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return e.Message + ": " + e.Err.Error()
+	}
+	return e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// InvalidArgument ошибка некорректного значения поля запроса.
+func InvalidArgument(field, message string) error {
+	return &Error{Kind: KindInvalidArgument, Field: field, Message: message}
+}
+
+// NotFound ошибка отсутствующего ресурса.
+func NotFound(message string) error {
+	return &Error{Kind: KindNotFound, Message: message}
+}
+
+// Unauthenticated ошибка отсутствующих или некорректных учетных данных.
+func Unauthenticated(message string) error {
+	return &Error{Kind: KindUnauthenticated, Message: message}
+}
+
+// PermissionDenied ошибка недостаточных прав для вызова.
+func PermissionDenied(message string) error {
+	return &Error{Kind: KindPermissionDenied, Message: message}
+}
+
+// ResourceExhausted ошибка превышения лимита запросов; retryAfter - через сколько можно повторить запрос.
+func ResourceExhausted(message string, retryAfter time.Duration) error {
+	return &Error{Kind: KindResourceExhausted, Message: message, RetryDelay: retryAfter}
+}
+
+// Unavailable ошибка временной недоступности зависимости dependency ("postgres", "kafka").
+func Unavailable(dependency, message string) error {
+	return &Error{Kind: KindUnavailable, Dependency: dependency, Message: message}
+}
+
+// FailedPrecondition ошибка операции, недопустимой в текущем состоянии ресурса.
+func FailedPrecondition(message string) error {
+	return &Error{Kind: KindFailedPrecondition, Message: message}
+}
+
+// Classify определяет класс ошибки и, для недоступности, зависимость, вызвавшую ошибку.
+func Classify(err error) (Kind, string) {
+	var appErr *Error
+	if errors.As(err, &appErr) {
+		return appErr.Kind, appErr.Dependency
+	}
+
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		return KindDeadlineExceeded, ""
+	case errors.Is(err, context.Canceled):
+		return KindCanceled, ""
+	}
+
+	// Ошибки PostgreSQL
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		switch pqErr.Code.Class() {
+		case "08", // connection_exception
+			"53", // insufficient_resources
+			"57": // operator_intervention (admin_shutdown, cannot_connect_now, ...)
+			return KindUnavailable, "postgres"
+		case "22": // data_exception
+			return KindInvalidArgument, ""
+		}
+		return KindInternal, ""
+	}
+	if errors.Is(err, driver.ErrBadConn) || errors.Is(err, sql.ErrConnDone) {
+		return KindUnavailable, "postgres"
+	}
+
+	// Ошибки Kafka
+	var kafkaErr kafka.Error
+	if errors.As(err, &kafkaErr) {
+		switch {
+		case kafkaErr == kafka.MessageSizeTooLarge:
+			return KindInvalidArgument, ""
+		case kafkaErr.Temporary(), kafkaErr == kafka.BrokerNotAvailable:
+			return KindUnavailable, "kafka"
+		}
+		return KindInternal, ""
+	}
+	var writeErrs kafka.WriteErrors
+	if errors.As(err, &writeErrs) {
+		for _, e := range writeErrs {
+			if e != nil {
+				return Classify(e)
+			}
+		}
+	}
+
+	// Сетевые ошибки: соединение с БД или брокером недоступно
+	var netErr net.Error
+	if errors.As(err, &netErr) || errors.Is(err, syscall.ECONNREFUSED) || errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, io.ErrUnexpectedEOF) {
+		return KindUnavailable, ""
+	}
+
+	return KindInternal, ""
+}
+
+// clientMessage возвращает сообщение об ошибке, безопасное для передачи клиенту.
+func clientMessage(err error, kind Kind) string {
+	var appErr *Error
+	if errors.As(err, &appErr) && appErr.Message != "" {
+		return appErr.Message
+	}
+
+	switch kind {
+	case KindInvalidArgument:
+		return "invalid argument"
+	case KindNotFound:
+		return "not found"
+	case KindUnavailable:
+		return "service temporarily unavailable, retry later"
+	case KindDeadlineExceeded:
+		return "deadline exceeded"
+	case KindCanceled:
+		return "request canceled"
+	case KindUnauthenticated:
+		return "unauthenticated"
+	case KindPermissionDenied:
+		return "permission denied"
+	case KindResourceExhausted:
+		return "rate limit exceeded"
+	case KindFailedPrecondition:
+		return "failed precondition"
+	default:
+		return "internal error"
+	}
+}
+
+// retryDelay возвращает рекомендуемую задержку перед повтором или 0, если повтор бессмысленен.
+func retryDelay(err error, kind Kind) time.Duration {
+	var appErr *Error
+	if errors.As(err, &appErr) && appErr.RetryDelay > 0 {
+		return appErr.RetryDelay
+	}
+	if kind == KindUnavailable {
+		return DefaultRetryDelay
+	}
+	return 0
+}
+
+// field возвращает поле запроса, к которому относится ошибка валидации.
+func field(err error) string {
+	var appErr *Error
+	if errors.As(err, &appErr) {
+		return appErr.Field
+	}
+	return ""
+}
@@ -0,0 +1,127 @@
+package apperrors
+
+import (
+	"log"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/protoadapt"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+// Code возвращает gRPC-код для класса ошибки.
+func (k Kind) Code() codes.Code {
+	switch k {
+	case KindInvalidArgument:
+		return codes.InvalidArgument
+	case KindNotFound:
+		return codes.NotFound
+	case KindUnavailable:
+		return codes.Unavailable
+	case KindDeadlineExceeded:
+		return codes.DeadlineExceeded
+	case KindCanceled:
+		return codes.Canceled
+	case KindUnauthenticated:
+		return codes.Unauthenticated
+	case KindPermissionDenied:
+		return codes.PermissionDenied
+	case KindResourceExhausted:
+		return codes.ResourceExhausted
+	case KindFailedPrecondition:
+		return codes.FailedPrecondition
+	default:
+		return codes.Internal
+	}
+}
+
+// ToGRPC преобразует ошибку в gRPC-статус с деталями ErrorInfo, RetryInfo и BadRequest.
+// Ошибки, уже являющиеся gRPC-статусом, возвращаются без изменений.
+func ToGRPC(err error) error {
+	if err == nil {
+		return nil
+	}
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+
+	kind, dependency := Classify(err)
+	st := status.New(kind.Code(), clientMessage(err, kind))
+
+	info := &errdetails.ErrorInfo{Reason: kind.String(), Domain: Domain}
+	if dependency != "" {
+		info.Metadata = map[string]string{"dependency": dependency}
+	}
+	details := []protoadapt.MessageV1{info}
+
+	if delay := retryDelay(err, kind); delay > 0 {
+		details = append(details, &errdetails.RetryInfo{RetryDelay: durationpb.New(delay)})
+	}
+	if f := field(err); f != "" {
+		details = append(details, &errdetails.BadRequest{
+			FieldViolations: []*errdetails.BadRequest_FieldViolation{{Field: f, Description: st.Message()}},
+		})
+	}
+
+	withDetails, detailsErr := st.WithDetails(details...)
+	if detailsErr != nil {
+		log.Printf("Error attaching error details: %v", detailsErr)
+		return st.Err()
+	}
+	return withDetails.Err()
+}
+
+// KindFromCode возвращает класс ошибки для gRPC-кода.
+func KindFromCode(code codes.Code) Kind {
+	switch code {
+	case codes.InvalidArgument, codes.OutOfRange:
+		return KindInvalidArgument
+	case codes.FailedPrecondition:
+		return KindFailedPrecondition
+	case codes.NotFound:
+		return KindNotFound
+	case codes.Unavailable:
+		return KindUnavailable
+	case codes.DeadlineExceeded:
+		return KindDeadlineExceeded
+	case codes.Canceled:
+		return KindCanceled
+	case codes.Unauthenticated:
+		return KindUnauthenticated
+	case codes.PermissionDenied:
+		return KindPermissionDenied
+	case codes.ResourceExhausted:
+		return KindResourceExhausted
+	default:
+		return KindInternal
+	}
+}
+
+// FromGRPC восстанавливает *Error из gRPC-статуса, созданного ToGRPC: класс по коду,
+// поле из BadRequest, зависимость из ErrorInfo и задержку из RetryInfo.
+// Используется HTTP-шлюзом, чтобы ответы с ошибками совпадали по формату с WriteHTTPError.
+func FromGRPC(err error) error {
+	if err == nil {
+		return nil
+	}
+	st, ok := status.FromError(err)
+	if !ok {
+		return err
+	}
+
+	appErr := &Error{Kind: KindFromCode(st.Code()), Message: st.Message()}
+	for _, detail := range st.Details() {
+		switch d := detail.(type) {
+		case *errdetails.ErrorInfo:
+			appErr.Dependency = d.Metadata["dependency"]
+		case *errdetails.RetryInfo:
+			appErr.RetryDelay = d.RetryDelay.AsDuration()
+		case *errdetails.BadRequest:
+			if len(d.FieldViolations) > 0 {
+				appErr.Field = d.FieldViolations[0].Field
+			}
+		}
+	}
+	return appErr
+}
@@ -0,0 +1,66 @@
+package apperrors
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// HTTPError тело ответа с ошибкой для HTTP API
+type HTTPError struct {
+	Error      string `json:"error"`
+	Code       string `json:"code"`
+	Field      string `json:"field,omitempty"`
+	Dependency string `json:"dependency,omitempty"`
+}
+
+// HTTPStatus возвращает HTTP-статус для класса ошибки.
+func (k Kind) HTTPStatus() int {
+	switch k {
+	case KindInvalidArgument:
+		return http.StatusBadRequest
+	case KindNotFound:
+		return http.StatusNotFound
+	case KindUnavailable:
+		return http.StatusServiceUnavailable
+	case KindDeadlineExceeded:
+		return http.StatusGatewayTimeout
+	case KindCanceled:
+		return 499 // Client Closed Request
+	case KindUnauthenticated:
+		return http.StatusUnauthorized
+	case KindPermissionDenied:
+		return http.StatusForbidden
+	case KindResourceExhausted:
+		return http.StatusTooManyRequests
+	case KindFailedPrecondition:
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// WriteHTTPError записывает ошибку в HTTP-ответ в формате JSON по той же классификации, что и для gRPC.
+// Для повторяемых ошибок устанавливается заголовок Retry-After.
+func WriteHTTPError(w http.ResponseWriter, err error) {
+	kind, dependency := Classify(err)
+
+	if delay := retryDelay(err, kind); delay > 0 {
+		seconds := int((delay + time.Second - 1) / time.Second) // Округление вверх до целых секунд
+		w.Header().Set("Retry-After", strconv.Itoa(seconds))
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(kind.HTTPStatus())
+
+	body := HTTPError{
+		Error:      clientMessage(err, kind),
+		Code:       kind.String(),
+		Field:      field(err),
+		Dependency: dependency,
+	}
+	if err := json.NewEncoder(w).Encode(body); err != nil {
+		log.Printf("Error encoding JSON error response: %v", err)
+	}
+}
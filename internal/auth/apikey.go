@@ -0,0 +1,61 @@
+package auth
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// APIKey описание статического API-ключа в файле ключей.
+type APIKey struct {
+	Key     string   `json:"key"`
+	Subject string   `json:"subject"`
+	Roles   []string `json:"roles"`
+}
+
+// APIKeyAuthenticator проверяет статические API-ключи. Ключи хранятся в памяти в виде SHA-256.
+type APIKeyAuthenticator struct {
+	keys map[[sha256.Size]byte]*Principal
+}
+
+// NewAPIKeyAuthenticator создаёт аутентификатор по списку ключей.
+func NewAPIKeyAuthenticator(keys []APIKey) *APIKeyAuthenticator {
+	a := &APIKeyAuthenticator{keys: make(map[[sha256.Size]byte]*Principal, len(keys))}
+	for _, k := range keys {
+		a.keys[sha256.Sum256([]byte(k.Key))] = &Principal{Subject: k.Subject, Roles: k.Roles, Method: "api-key"}
+	}
+	return a
+}
+
+// LoadAPIKeys читает JSON-файл со списком ключей:
+// [{"key": "...", "subject": "batch-job", "roles": ["writer"]}]
+func LoadAPIKeys(path string) ([]APIKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка чтения файла API-ключей: %v", err)
+	}
+	var keys []APIKey
+	if err := json.Unmarshal(data, &keys); err != nil {
+		return nil, fmt.Errorf("ошибка разбора файла API-ключей: %v", err)
+	}
+	for i, k := range keys {
+		if k.Key == "" || k.Subject == "" {
+			return nil, fmt.Errorf("API-ключ #%d: key и subject обязательны", i)
+		}
+	}
+	return keys, nil
+}
+
+// Authenticate реализует Authenticator.
+func (a *APIKeyAuthenticator) Authenticate(_ context.Context, creds Credentials) (*Principal, error) {
+	if creds.APIKey == "" {
+		return nil, ErrNoCredentials
+	}
+	p, ok := a.keys[sha256.Sum256([]byte(creds.APIKey))]
+	if !ok {
+		return nil, ErrInvalidCredentials
+	}
+	return p, nil
+}
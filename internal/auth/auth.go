@@ -0,0 +1,87 @@
+// Package auth аутентификация вызывающих (API-ключи, JWT) и авторизация вызовов по ролям
+// для gRPC-сервера и HTTP API.
+package auth
+
+import (
+	"context"
+	"errors"
+)
+
+// Стандартные роли
+const (
+	RoleReader = "reader" // Чтение сообщений и статистики
+	RoleWriter = "writer" // Отправка сообщений
+	RoleAdmin  = "admin"  // Доступ ко всем методам
+)
+
+var (
+	// ErrNoCredentials запрос не содержит учетных данных, которые понимает аутентификатор
+	ErrNoCredentials = errors.New("учетные данные не переданы")
+	// ErrInvalidCredentials учетные данные переданы, но не прошли проверку
+	ErrInvalidCredentials = errors.New("некорректные учетные данные")
+)
+
+// Principal аутентифицированный вызывающий.
+type Principal struct {
+	Subject string   // Идентификатор клиента (subject токена или имя API-ключа)
+	Roles   []string // Роли клиента
+	Method  string   // Способ аутентификации: "api-key" или "jwt"
+}
+
+// HasRole проверяет наличие роли; роль admin подходит для любой проверки.
+func (p *Principal) HasRole(role string) bool {
+	for _, r := range p.Roles {
+		if r == role || r == RoleAdmin {
+			return true
+		}
+	}
+	return false
+}
+
+// Credentials учетные данные, извлеченные из запроса.
+type Credentials struct {
+	APIKey      string // Заголовок X-API-Key / метаданные x-api-key
+	BearerToken string // Заголовок Authorization: Bearer <token>
+}
+
+// Authenticator проверяет учетные данные. Если подходящих учетных данных нет,
+// возвращает ErrNoCredentials, чтобы можно было попробовать следующий аутентификатор.
+type Authenticator interface {
+	Authenticate(ctx context.Context, creds Credentials) (*Principal, error)
+}
+
+// Chain последовательно пробует аутентификаторы до первого, распознавшего учетные данные.
+type Chain []Authenticator
+
+// Authenticate реализует Authenticator.
+func (c Chain) Authenticate(ctx context.Context, creds Credentials) (*Principal, error) {
+	for _, a := range c {
+		p, err := a.Authenticate(ctx, creds)
+		if errors.Is(err, ErrNoCredentials) {
+			continue
+		}
+		return p, err
+	}
+	return nil, ErrNoCredentials
+}
+
+type principalKey struct{}
+
+// NewContext возвращает контекст с аутентифицированным вызывающим.
+func NewContext(ctx context.Context, p *Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, p)
+}
+
+// FromContext возвращает вызывающего, сохраненного в контексте.
+func FromContext(ctx context.Context) (*Principal, bool) {
+	p, ok := ctx.Value(principalKey{}).(*Principal)
+	return p, ok
+}
+
+// SubjectFromContext возвращает идентификатор вызывающего или пустую строку, если аутентификация отключена.
+func SubjectFromContext(ctx context.Context) string {
+	if p, ok := FromContext(ctx); ok {
+		return p.Subject
+	}
+	return ""
+}
@@ -0,0 +1,47 @@
+package auth
+
+import (
+	"fmt"
+	"go_micro_gRPS/config"
+)
+
+// NewAuthorizerFromConfig собирает Authorizer из настроек: API-ключи из файла,
+// JWT HS256 с общим секретом и/или RS256 с ключами из локального JWKS-файла.
+func NewAuthorizerFromConfig(cfg config.Config) (*Authorizer, error) {
+	var chain Chain
+
+	if cfg.AuthAPIKeysFile != "" {
+		keys, err := LoadAPIKeys(cfg.AuthAPIKeysFile)
+		if err != nil {
+			return nil, err
+		}
+		chain = append(chain, NewAPIKeyAuthenticator(keys))
+	}
+
+	if cfg.AuthJWTHMACSecret != "" || cfg.AuthJWKSFile != "" {
+		jwtCfg := JWTConfig{
+			HMACSecret: []byte(cfg.AuthJWTHMACSecret),
+			Issuer:     cfg.AuthJWTIssuer,
+			Audience:   cfg.AuthJWTAudience,
+			RolesClaim: cfg.AuthJWTRolesClaim,
+		}
+		if cfg.AuthJWKSFile != "" {
+			keys, err := LoadJWKS(cfg.AuthJWKSFile)
+			if err != nil {
+				return nil, err
+			}
+			jwtCfg.RSAKeys = keys
+		}
+		chain = append(chain, NewJWTAuthenticator(jwtCfg))
+	}
+
+	if len(chain) == 0 {
+		return nil, fmt.Errorf("аутентификация включена, но не настроены ни API-ключи, ни JWT")
+	}
+
+	policy, err := LoadPolicy(cfg.AuthPolicyFile)
+	if err != nil {
+		return nil, err
+	}
+	return NewAuthorizer(chain, policy), nil
+}
@@ -0,0 +1,63 @@
+package auth
+
+import (
+	"context"
+	"go_micro_gRPS/internal/apperrors"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// UnaryServerInterceptor проверяет доступ к unary-методам gRPC.
+func (a *Authorizer) UnaryServerInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	ctx, err := a.Authorize(ctx, info.FullMethod, grpcCredentials(ctx))
+	if err != nil {
+		return nil, apperrors.ToGRPC(err)
+	}
+	return handler(ctx, req)
+}
+
+// StreamServerInterceptor проверяет доступ к потоковым методам gRPC.
+func (a *Authorizer) StreamServerInterceptor(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	ctx, err := a.Authorize(ss.Context(), info.FullMethod, grpcCredentials(ss.Context()))
+	if err != nil {
+		return apperrors.ToGRPC(err)
+	}
+	return handler(srv, &principalStream{ServerStream: ss, ctx: ctx})
+}
+
+// grpcCredentials извлекает учетные данные из метаданных authorization и x-api-key.
+func grpcCredentials(ctx context.Context) Credentials {
+	var creds Credentials
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return creds
+	}
+	if values := md.Get("x-api-key"); len(values) > 0 {
+		creds.APIKey = values[0]
+	}
+	if values := md.Get("authorization"); len(values) > 0 {
+		creds.BearerToken = bearerToken(values[0])
+	}
+	return creds
+}
+
+// bearerToken извлекает токен из значения "Bearer <token>".
+func bearerToken(header string) string {
+	const prefix = "bearer "
+	if len(header) > len(prefix) && strings.EqualFold(header[:len(prefix)], prefix) {
+		return strings.TrimSpace(header[len(prefix):])
+	}
+	return ""
+}
+
+// principalStream ServerStream с контекстом, содержащим вызывающего.
+type principalStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *principalStream) Context() context.Context {
+	return s.ctx
+}
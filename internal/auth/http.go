@@ -0,0 +1,22 @@
+package auth
+
+import (
+	"go_micro_gRPS/internal/apperrors"
+	"net/http"
+)
+
+// HTTPMiddleware проверяет доступ к HTTP-маршруту. Операция определяется как "<METHOD> <path>".
+func (a *Authorizer) HTTPMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		creds := Credentials{
+			APIKey:      r.Header.Get("X-API-Key"),
+			BearerToken: bearerToken(r.Header.Get("Authorization")),
+		}
+		ctx, err := a.Authorize(r.Context(), r.Method+" "+r.URL.Path, creds)
+		if err != nil {
+			apperrors.WriteHTTPError(w, err)
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
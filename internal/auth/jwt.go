@@ -0,0 +1,256 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+	"time"
+)
+
+// jwtLeeway допустимое расхождение часов при проверке exp и nbf
+const jwtLeeway = 30 * time.Second
+
+// JWTConfig параметры проверки JWT.
+type JWTConfig struct {
+	HMACSecret []byte                    // Секрет для HS256 (пусто - HS256 не принимается)
+	RSAKeys    map[string]*rsa.PublicKey // Ключи для RS256 по kid (из JWKS-файла)
+	Issuer     string                    // Ожидаемый iss (пусто - не проверяется)
+	Audience   string                    // Ожидаемый aud (пусто - не проверяется)
+	RolesClaim string                    // Имя claim со списком ролей (по умолчанию "roles")
+}
+
+// JWTAuthenticator проверяет bearer-токены JWT, подписанные HS256 или RS256.
+type JWTAuthenticator struct {
+	cfg JWTConfig
+	now func() time.Time
+}
+
+// NewJWTAuthenticator создаёт аутентификатор JWT.
+func NewJWTAuthenticator(cfg JWTConfig) *JWTAuthenticator {
+	if cfg.RolesClaim == "" {
+		cfg.RolesClaim = "roles"
+	}
+	return &JWTAuthenticator{cfg: cfg, now: time.Now}
+}
+
+// Authenticate реализует Authenticator.
+func (a *JWTAuthenticator) Authenticate(_ context.Context, creds Credentials) (*Principal, error) {
+	if creds.BearerToken == "" {
+		return nil, ErrNoCredentials
+	}
+	p, err := a.verify(creds.BearerToken)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidCredentials, err)
+	}
+	return p, nil
+}
+
+type jwtHeader struct {
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+}
+
+// verify проверяет подпись и стандартные claims токена.
+func (a *JWTAuthenticator) verify(token string) (*Principal, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("токен должен состоять из трёх частей")
+	}
+
+	var header jwtHeader
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return nil, fmt.Errorf("некорректный заголовок: %v", err)
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("некорректная подпись: %v", err)
+	}
+	signed := []byte(parts[0] + "." + parts[1])
+
+	switch header.Alg {
+	case "HS256":
+		if len(a.cfg.HMACSecret) == 0 {
+			return nil, fmt.Errorf("алгоритм HS256 не разрешен")
+		}
+		mac := hmac.New(sha256.New, a.cfg.HMACSecret)
+		mac.Write(signed)
+		if !hmac.Equal(mac.Sum(nil), signature) {
+			return nil, fmt.Errorf("неверная подпись")
+		}
+	case "RS256":
+		key, err := a.rsaKey(header.Kid)
+		if err != nil {
+			return nil, err
+		}
+		digest := sha256.Sum256(signed)
+		if err := rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], signature); err != nil {
+			return nil, fmt.Errorf("неверная подпись")
+		}
+	default:
+		return nil, fmt.Errorf("алгоритм %q не поддерживается", header.Alg)
+	}
+
+	var claims map[string]json.RawMessage
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return nil, fmt.Errorf("некорректные claims: %v", err)
+	}
+	return a.checkClaims(claims)
+}
+
+// rsaKey выбирает ключ по kid; без kid допускается только единственный ключ.
+func (a *JWTAuthenticator) rsaKey(kid string) (*rsa.PublicKey, error) {
+	if kid != "" {
+		if key, ok := a.cfg.RSAKeys[kid]; ok {
+			return key, nil
+		}
+		return nil, fmt.Errorf("неизвестный kid %q", kid)
+	}
+	if len(a.cfg.RSAKeys) == 1 {
+		for _, key := range a.cfg.RSAKeys {
+			return key, nil
+		}
+	}
+	return nil, fmt.Errorf("алгоритм RS256 требует kid")
+}
+
+// checkClaims проверяет exp, nbf, iss, aud и извлекает subject и роли.
+func (a *JWTAuthenticator) checkClaims(claims map[string]json.RawMessage) (*Principal, error) {
+	now := a.now()
+
+	var exp, nbf *float64
+	if err := decodeClaim(claims, "exp", &exp); err != nil {
+		return nil, err
+	}
+	if err := decodeClaim(claims, "nbf", &nbf); err != nil {
+		return nil, err
+	}
+	if exp == nil {
+		return nil, fmt.Errorf("отсутствует exp")
+	}
+	if now.After(time.Unix(int64(*exp), 0).Add(jwtLeeway)) {
+		return nil, fmt.Errorf("срок действия токена истёк")
+	}
+	if nbf != nil && now.Add(jwtLeeway).Before(time.Unix(int64(*nbf), 0)) {
+		return nil, fmt.Errorf("токен ещё не действителен")
+	}
+
+	var iss, sub string
+	if err := decodeClaim(claims, "iss", &iss); err != nil {
+		return nil, err
+	}
+	if a.cfg.Issuer != "" && iss != a.cfg.Issuer {
+		return nil, fmt.Errorf("неверный iss")
+	}
+
+	if a.cfg.Audience != "" {
+		// aud может быть строкой или массивом строк
+		var audiences []string
+		var single string
+		if err := decodeClaim(claims, "aud", &single); err == nil && single != "" {
+			audiences = []string{single}
+		} else if err := decodeClaim(claims, "aud", &audiences); err != nil {
+			return nil, err
+		}
+		if !contains(audiences, a.cfg.Audience) {
+			return nil, fmt.Errorf("неверный aud")
+		}
+	}
+
+	if err := decodeClaim(claims, "sub", &sub); err != nil {
+		return nil, err
+	}
+	if sub == "" {
+		return nil, fmt.Errorf("отсутствует sub")
+	}
+
+	var roles []string
+	if err := decodeClaim(claims, a.cfg.RolesClaim, &roles); err != nil {
+		return nil, err
+	}
+	return &Principal{Subject: sub, Roles: roles, Method: "jwt"}, nil
+}
+
+// LoadJWKS читает RSA-ключи из локального JWKS-файла.
+func LoadJWKS(path string) (map[string]*rsa.PublicKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка чтения JWKS: %v", err)
+	}
+
+	var jwks struct {
+		Keys []struct {
+			Kty string `json:"kty"`
+			Kid string `json:"kid"`
+			Use string `json:"use"`
+			N   string `json:"n"`
+			E   string `json:"e"`
+		} `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &jwks); err != nil {
+		return nil, fmt.Errorf("ошибка разбора JWKS: %v", err)
+	}
+
+	keys := make(map[string]*rsa.PublicKey)
+	for _, k := range jwks.Keys {
+		if k.Kty != "RSA" || (k.Use != "" && k.Use != "sig") {
+			continue
+		}
+		n, err := base64.RawURLEncoding.DecodeString(k.N)
+		if err != nil {
+			return nil, fmt.Errorf("ключ %q: некорректный модуль: %v", k.Kid, err)
+		}
+		e, err := base64.RawURLEncoding.DecodeString(k.E)
+		if err != nil {
+			return nil, fmt.Errorf("ключ %q: некорректная экспонента: %v", k.Kid, err)
+		}
+		// crypto/rsa принимает экспоненту только в пределах int32
+		exponent := new(big.Int).SetBytes(e)
+		if exponent.BitLen() > 31 || exponent.Int64() < 3 {
+			return nil, fmt.Errorf("ключ %q: недопустимая экспонента", k.Kid)
+		}
+		keys[k.Kid] = &rsa.PublicKey{
+			N: new(big.Int).SetBytes(n),
+			E: int(exponent.Int64()),
+		}
+	}
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("в JWKS нет RSA-ключей для подписи")
+	}
+	return keys, nil
+}
+
+func decodeSegment(segment string, v interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+func decodeClaim(claims map[string]json.RawMessage, name string, v interface{}) error {
+	raw, ok := claims[name]
+	if !ok {
+		return nil
+	}
+	if err := json.Unmarshal(raw, v); err != nil {
+		return fmt.Errorf("некорректный claim %s", name)
+	}
+	return nil
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,259 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+var (
+	testSecret = []byte("test-secret")
+	testNow    = time.Unix(1_700_000_000, 0)
+
+	testKeyOnce sync.Once
+	testKey     *rsa.PrivateKey
+)
+
+// rsaTestKey возвращает RSA-ключ, общий для всех тестов пакета.
+func rsaTestKey(t *testing.T) *rsa.PrivateKey {
+	t.Helper()
+	testKeyOnce.Do(func() {
+		var err error
+		if testKey, err = rsa.GenerateKey(rand.Reader, 2048); err != nil {
+			t.Fatal(err)
+		}
+	})
+	return testKey
+}
+
+func encodeSegment(t *testing.T, v interface{}) string {
+	t.Helper()
+	data, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// signToken собирает токен с заголовком header и claims, подписанный по алгоритму из заголовка
+// (HS256 - testSecret, RS256 - rsaTestKey, иначе - пустая подпись).
+func signToken(t *testing.T, header, claims map[string]interface{}) string {
+	t.Helper()
+	signed := encodeSegment(t, header) + "." + encodeSegment(t, claims)
+	var signature []byte
+	switch header["alg"] {
+	case "HS256":
+		mac := hmac.New(sha256.New, testSecret)
+		mac.Write([]byte(signed))
+		signature = mac.Sum(nil)
+	case "RS256":
+		digest := sha256.Sum256([]byte(signed))
+		var err error
+		if signature, err = rsa.SignPKCS1v15(rand.Reader, rsaTestKey(t), crypto.SHA256, digest[:]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return signed + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+// validClaims claims токена, который проходит все проверки testAuthenticator.
+func validClaims() map[string]interface{} {
+	return map[string]interface{}{
+		"sub":   "svc",
+		"iss":   "issuer",
+		"aud":   "api",
+		"exp":   testNow.Add(time.Hour).Unix(),
+		"roles": []string{RoleWriter},
+	}
+}
+
+// testAuthenticator аутентификатор с часами testNow; hmac и rsa включают соответствующие алгоритмы.
+func testAuthenticator(t *testing.T, withHMAC, withRSA bool) *JWTAuthenticator {
+	t.Helper()
+	cfg := JWTConfig{Issuer: "issuer", Audience: "api"}
+	if withHMAC {
+		cfg.HMACSecret = testSecret
+	}
+	if withRSA {
+		cfg.RSAKeys = map[string]*rsa.PublicKey{"key-1": &rsaTestKey(t).PublicKey}
+	}
+	a := NewJWTAuthenticator(cfg)
+	a.now = func() time.Time { return testNow }
+	return a
+}
+
+func TestJWTAuthenticate(t *testing.T) {
+	hs256 := map[string]interface{}{"alg": "HS256"}
+	rs256 := map[string]interface{}{"alg": "RS256", "kid": "key-1"}
+	with := func(changes map[string]interface{}) map[string]interface{} {
+		claims := validClaims()
+		for name, value := range changes {
+			if value == nil {
+				delete(claims, name)
+				continue
+			}
+			claims[name] = value
+		}
+		return claims
+	}
+	tamper := func(token string) string {
+		// Замена первого символа подписи меняет её первый байт
+		i := strings.LastIndex(token, ".") + 1
+		c := byte('A')
+		if token[i] == 'A' {
+			c = 'B'
+		}
+		return token[:i] + string(c) + token[i+1:]
+	}
+
+	tests := []struct {
+		name      string
+		hmac, rsa bool
+		token     string
+		wantErr   string // Фрагмент ошибки; пусто - токен принимается
+	}{
+		{"HS256", true, true, signToken(t, hs256, validClaims()), ""},
+		{"RS256", true, true, signToken(t, rs256, validClaims()), ""},
+		{"RS256 без kid с единственным ключом", false, true, signToken(t, map[string]interface{}{"alg": "RS256"}, validClaims()), ""},
+		{"aud массивом", true, false, signToken(t, hs256, with(map[string]interface{}{"aud": []string{"other", "api"}})), ""},
+		{"exp в пределах расхождения часов", true, false, signToken(t, hs256, with(map[string]interface{}{"exp": testNow.Add(-jwtLeeway / 2).Unix()})), ""},
+		{"nbf в прошлом", true, false, signToken(t, hs256, with(map[string]interface{}{"nbf": testNow.Add(-time.Minute).Unix()})), ""},
+
+		{"не три части", true, true, "a.b", "трёх частей"},
+		{"alg none", true, true, signToken(t, map[string]interface{}{"alg": "none"}, validClaims()), "не поддерживается"},
+		{"alg none с точкой в конце", true, true, encodeSegment(t, map[string]string{"alg": "none"}) + "." + encodeSegment(t, validClaims()) + ".", "не поддерживается"},
+		{"неизвестный alg", true, true, signToken(t, map[string]interface{}{"alg": "HS512"}, validClaims()), "не поддерживается"},
+		{"HS256 только с JWKS", false, true, signToken(t, hs256, validClaims()), "HS256 не разрешен"},
+		{"RS256 только с секретом HMAC", true, false, signToken(t, rs256, validClaims()), "неизвестный kid"},
+		{"RS256 без kid только с секретом HMAC", true, false, signToken(t, map[string]interface{}{"alg": "RS256"}, validClaims()), "требует kid"},
+		{"неизвестный kid", true, true, signToken(t, map[string]interface{}{"alg": "RS256", "kid": "key-2"}, validClaims()), "неизвестный kid"},
+		{"изменённая подпись HS256", true, true, tamper(signToken(t, hs256, validClaims())), "неверная подпись"},
+		{"изменённая подпись RS256", true, true, tamper(signToken(t, rs256, validClaims())), "неверная подпись"},
+		{"подмена claims", true, true, func() string {
+			parts := strings.Split(signToken(t, hs256, validClaims()), ".")
+			parts[1] = encodeSegment(t, with(map[string]interface{}{"sub": "admin"}))
+			return strings.Join(parts, ".")
+		}(), "неверная подпись"},
+		{"истёкший токен", true, true, signToken(t, hs256, with(map[string]interface{}{"exp": testNow.Add(-time.Hour).Unix()})), "истёк"},
+		{"без exp", true, true, signToken(t, hs256, with(map[string]interface{}{"exp": nil})), "отсутствует exp"},
+		{"до nbf", true, true, signToken(t, hs256, with(map[string]interface{}{"nbf": testNow.Add(time.Hour).Unix()})), "ещё не действителен"},
+		{"неверный iss", true, true, signToken(t, hs256, with(map[string]interface{}{"iss": "other"})), "неверный iss"},
+		{"без iss", true, true, signToken(t, hs256, with(map[string]interface{}{"iss": nil})), "неверный iss"},
+		{"неверный aud строкой", true, true, signToken(t, hs256, with(map[string]interface{}{"aud": "other"})), "неверный aud"},
+		{"неверный aud массивом", true, true, signToken(t, hs256, with(map[string]interface{}{"aud": []string{"other", "api2"}})), "неверный aud"},
+		{"без aud", true, true, signToken(t, hs256, with(map[string]interface{}{"aud": nil})), "неверный aud"},
+		{"без sub", true, true, signToken(t, hs256, with(map[string]interface{}{"sub": nil})), "отсутствует sub"},
+		{"пустой sub", true, true, signToken(t, hs256, with(map[string]interface{}{"sub": ""})), "отсутствует sub"},
+		{"роли не массивом", true, true, signToken(t, hs256, with(map[string]interface{}{"roles": "writer"})), "claim roles"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := testAuthenticator(t, tt.hmac, tt.rsa).Authenticate(context.Background(), Credentials{BearerToken: tt.token})
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("токен отклонён: %v", err)
+				}
+				if p.Subject != "svc" || !p.HasRole(RoleWriter) || p.Method != "jwt" {
+					t.Errorf("вызывающий %+v", p)
+				}
+				return
+			}
+			if !errors.Is(err, ErrInvalidCredentials) || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("ошибка %v, ожидалась %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestJWTNoCredentials(t *testing.T) {
+	_, err := testAuthenticator(t, true, false).Authenticate(context.Background(), Credentials{APIKey: "key"})
+	if !errors.Is(err, ErrNoCredentials) {
+		t.Errorf("ошибка %v, ожидалась ErrNoCredentials", err)
+	}
+}
+
+// jwk запись JWKS с RSA-ключом.
+func jwk(kid string, key *rsa.PublicKey) map[string]string {
+	return map[string]string{
+		"kty": "RSA",
+		"kid": kid,
+		"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+		"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+	}
+}
+
+func writeJWKS(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadJWKS(t *testing.T) {
+	public := &rsaTestKey(t).PublicKey
+	withExponent := func(e []byte) map[string]string {
+		k := jwk("key-1", public)
+		k["e"] = base64.RawURLEncoding.EncodeToString(e)
+		return k
+	}
+	encode := func(keys ...interface{}) string {
+		data, err := json.Marshal(map[string]interface{}{"keys": keys})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(data)
+	}
+
+	// Ключи шифрования и ключи других типов пропускаются
+	enc := jwk("enc", public)
+	enc["use"] = "enc"
+	path := writeJWKS(t, encode(jwk("key-1", public), enc, map[string]string{"kty": "EC", "kid": "ec"}))
+	keys, err := LoadJWKS(path)
+	if err != nil {
+		t.Fatalf("LoadJWKS: %v", err)
+	}
+	if len(keys) != 1 || !public.Equal(keys["key-1"]) {
+		t.Errorf("загружены ключи %v, ожидался только key-1", keys)
+	}
+
+	tests := []struct {
+		name    string
+		content string
+		wantErr string
+	}{
+		{"некорректный JSON", "{", "ошибка разбора"},
+		{"нет ключей подписи", encode(enc), "нет RSA-ключей"},
+		{"некорректный модуль", encode(map[string]string{"kty": "RSA", "kid": "key-1", "n": "!", "e": "AQAB"}), "некорректный модуль"},
+		{"некорректная экспонента", encode(map[string]string{"kty": "RSA", "kid": "key-1", "n": "AQAB", "e": "!"}), "некорректная экспонента"},
+		{"экспонента 1", encode(withExponent([]byte{1})), "недопустимая экспонента"},
+		{"пустая экспонента", encode(withExponent(nil)), "недопустимая экспонента"},
+		{"экспонента больше int32", encode(withExponent([]byte{1, 0, 0, 0, 1})), "недопустимая экспонента"},
+		{"экспонента больше int64", encode(withExponent([]byte{1, 0, 0, 0, 0, 0, 0, 0, 1})), "недопустимая экспонента"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := LoadJWKS(writeJWKS(t, tt.content)); err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("ошибка %v, ожидалась %q", err, tt.wantErr)
+			}
+		})
+	}
+
+	if _, err := LoadJWKS(filepath.Join(t.TempDir(), "missing.json")); err == nil {
+		t.Error("LoadJWKS несуществующего файла: ожидалась ошибка")
+	}
+}
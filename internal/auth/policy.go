@@ -0,0 +1,101 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go_micro_gRPS/internal/apperrors"
+	"log"
+	"os"
+)
+
+// Public список ролей, означающий, что операция доступна без аутентификации
+const Public = "*"
+
+// Policy разрешенные роли для каждой операции. Операция - полное имя gRPC-метода
+// ("/service.MessageService/SendMessage") или HTTP-маршрут ("GET /api/consume").
+// REST API шлюза проверяется по именам gRPC-методов, в которые транскодируются запросы.
+// Операции, отсутствующие в политике, запрещены.
+type Policy map[string][]string
+
+// DefaultPolicy политика по умолчанию: отправка - writer, чтение - reader, health-проверки - без аутентификации.
+func DefaultPolicy() Policy {
+	return Policy{
+		"/service.MessageService/SendMessage":          {RoleWriter},
+		"/service.MessageService/SendMessageStream":    {RoleWriter},
+		"/service.MessageService/BatchSendMessages":    {RoleWriter},
+		"/service.MessageService/RetryMessage":         {RoleWriter},
+		"/service.MessageService/GetProcessedMessages": {RoleReader},
+		"/service.MessageService/GetStats":             {RoleReader},
+		"/service.MessageService/GetMessage":           {RoleReader},
+		"/service.MessageService/ListMessages":         {RoleReader},
+		"/service.MessageService/SubscribeMessages":    {RoleReader},
+		"/grpc.health.v1.Health/Check":                 {Public},
+		"/grpc.health.v1.Health/Watch":                 {Public},
+
+		"GET /api/consume": {RoleReader},
+
+		"GET /api/ratelimits": {RoleAdmin},
+	}
+}
+
+// LoadPolicy читает JSON-файл вида {"GET /api/consume": ["reader"]} и дополняет им политику по умолчанию.
+func LoadPolicy(path string) (Policy, error) {
+	policy := DefaultPolicy()
+	if path == "" {
+		return policy, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка чтения политики доступа: %v", err)
+	}
+	var overrides Policy
+	if err := json.Unmarshal(data, &overrides); err != nil {
+		return nil, fmt.Errorf("ошибка разбора политики доступа: %v", err)
+	}
+	for operation, roles := range overrides {
+		policy[operation] = roles
+	}
+	return policy, nil
+}
+
+// Authorizer аутентифицирует вызывающего и проверяет его роли по политике.
+type Authorizer struct {
+	authenticator Authenticator
+	policy        Policy
+}
+
+// NewAuthorizer создаёт Authorizer.
+func NewAuthorizer(authenticator Authenticator, policy Policy) *Authorizer {
+	return &Authorizer{authenticator: authenticator, policy: policy}
+}
+
+// Authorize проверяет доступ к операции и возвращает контекст с вызывающим.
+// Ошибки классифицированы через apperrors (Unauthenticated / PermissionDenied).
+func (a *Authorizer) Authorize(ctx context.Context, operation string, creds Credentials) (context.Context, error) {
+	roles, ok := a.policy[operation]
+	if !ok {
+		return nil, apperrors.PermissionDenied("operation is not allowed")
+	}
+	if contains(roles, Public) {
+		return ctx, nil
+	}
+
+	p, err := a.authenticator.Authenticate(ctx, creds)
+	if errors.Is(err, ErrNoCredentials) {
+		return nil, apperrors.Unauthenticated("credentials required")
+	}
+	if err != nil {
+		log.Printf("Ошибка аутентификации (%s): %v", operation, err)
+		return nil, apperrors.Unauthenticated("invalid credentials")
+	}
+
+	for _, role := range roles {
+		if p.HasRole(role) {
+			return NewContext(ctx, p), nil
+		}
+	}
+	return nil, apperrors.PermissionDenied(fmt.Sprintf("role %v required", roles))
+}
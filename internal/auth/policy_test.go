@@ -0,0 +1,97 @@
+package auth
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"go_micro_gRPS/internal/apperrors"
+)
+
+func TestAuthorize(t *testing.T) {
+	const (
+		sendMessage = "/service.MessageService/SendMessage"
+		getMessage  = "/service.MessageService/GetMessage"
+		health      = "/grpc.health.v1.Health/Check"
+	)
+	a := NewAuthorizer(NewAPIKeyAuthenticator([]APIKey{
+		{Key: "reader-key", Subject: "reader", Roles: []string{RoleReader}},
+		{Key: "writer-key", Subject: "writer", Roles: []string{RoleWriter}},
+		{Key: "admin-key", Subject: "admin", Roles: []string{RoleAdmin}},
+	}), DefaultPolicy())
+
+	tests := []struct {
+		name      string
+		operation string
+		apiKey    string
+		wantKind  apperrors.Kind // KindInternal - доступ разрешен
+		subject   string         // Вызывающий в возвращенном контексте
+	}{
+		{"writer отправляет", sendMessage, "writer-key", apperrors.KindInternal, "writer"},
+		{"admin отправляет", sendMessage, "admin-key", apperrors.KindInternal, "admin"},
+		{"reader читает", getMessage, "reader-key", apperrors.KindInternal, "reader"},
+		{"HTTP-маршрут", "GET /api/consume", "reader-key", apperrors.KindInternal, "reader"},
+		{"публичный метод без учетных данных", health, "", apperrors.KindInternal, ""},
+		{"публичный метод с неверным ключом", health, "unknown", apperrors.KindInternal, ""},
+
+		{"reader отправляет", sendMessage, "reader-key", apperrors.KindPermissionDenied, ""},
+		{"writer читает", getMessage, "writer-key", apperrors.KindPermissionDenied, ""},
+		{"без учетных данных", sendMessage, "", apperrors.KindUnauthenticated, ""},
+		{"неверный ключ", sendMessage, "unknown", apperrors.KindUnauthenticated, ""},
+		{"метод вне политики", "/service.MessageService/DeleteMessage", "admin-key", apperrors.KindPermissionDenied, ""},
+		{"метод вне политики без учетных данных", "/service.MessageService/DeleteMessage", "", apperrors.KindPermissionDenied, ""},
+		{"HTTP-метод вне политики", "POST /api/consume", "admin-key", apperrors.KindPermissionDenied, ""},
+		{"административный маршрут для writer", "GET /api/ratelimits", "writer-key", apperrors.KindPermissionDenied, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, err := a.Authorize(context.Background(), tt.operation, Credentials{APIKey: tt.apiKey})
+			if tt.wantKind != apperrors.KindInternal {
+				if kind, _ := apperrors.Classify(err); err == nil || kind != tt.wantKind {
+					t.Errorf("ошибка %v, ожидался класс %v", err, tt.wantKind)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("доступ запрещен: %v", err)
+			}
+			if subject := SubjectFromContext(ctx); subject != tt.subject {
+				t.Errorf("вызывающий %q, ожидался %q", subject, tt.subject)
+			}
+		})
+	}
+}
+
+func TestLoadPolicy(t *testing.T) {
+	policy, err := LoadPolicy("")
+	if err != nil || len(policy) != len(DefaultPolicy()) {
+		t.Fatalf("LoadPolicy без файла: %d операций, ошибка %v", len(policy), err)
+	}
+
+	path := filepath.Join(t.TempDir(), "policy.json")
+	overrides := `{"/service.MessageService/GetStats": ["admin"], "GET /api/export": ["reader"]}`
+	if err := os.WriteFile(path, []byte(overrides), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if policy, err = LoadPolicy(path); err != nil {
+		t.Fatalf("LoadPolicy: %v", err)
+	}
+	// Файл заменяет роли указанных операций и добавляет новые, остальные остаются по умолчанию
+	for operation, want := range map[string]string{
+		"/service.MessageService/GetStats":    RoleAdmin,
+		"GET /api/export":                     RoleReader,
+		"/service.MessageService/SendMessage": RoleWriter,
+	} {
+		if roles := policy[operation]; len(roles) != 1 || roles[0] != want {
+			t.Errorf("%s: роли %v, ожидалась %s", operation, roles, want)
+		}
+	}
+
+	if err := os.WriteFile(path, []byte("["), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadPolicy(path); err == nil {
+		t.Error("LoadPolicy некорректного файла: ожидалась ошибка")
+	}
+}
@@ -0,0 +1,109 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"go_micro_gRPS/internal/models"
+	"time"
+)
+
+// ErrIdempotencyKeyReused ключ идемпотентности уже использован для другого запроса
+var ErrIdempotencyKeyReused = errors.New("ключ идемпотентности использован для другого запроса")
+
+// IdempotencyKey ключ идемпотентности отправки. Ключи уникальны в пределах отправителя.
+type IdempotencyKey struct {
+	Principal   string        // Отправитель: аутентифицированный клиент, а без аутентификации - его адрес
+	Key         string        // Ключ, переданный клиентом
+	RequestHash string        // Хеш запроса для обнаружения повторного использования ключа
+	Retention   time.Duration // Время хранения ключа; по истечении ключ можно использовать заново
+}
+
+// SaveMessageIdempotent сохраняет сообщение и закрепляет за ним ключ идемпотентности в одной
+// короткой транзакции. Отправку в Kafka и обновление статуса выполняет вызывающий после её
+// завершения, поэтому соединение с БД и блокировка ключа не удерживаются на время отправки.
+//
+// Если ключ уже закреплен за сообщением, возвращаются ID и текущий статус этого сообщения
+// и duplicate = true. Конкурентный запрос с тем же ключом ожидает на уникальном индексе
+// завершения первой транзакции. Ключ не освобождается и после неудачной отправки: повтор
+// запроса получает то же сообщение со статусом 'failed', повторная отправка - через RetryMessage.
+func SaveMessageIdempotent(ctx context.Context, db *sql.DB, msg models.Message, key IdempotencyKey) (id int, status string, duplicate bool, err error) {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, "", false, err
+	}
+	defer tx.Rollback()
+
+	// Истекший, но ещё не удаленный ключ занимается заново
+	var claimed bool
+	err = tx.QueryRowContext(ctx, `INSERT INTO idempotency_keys (principal, idempotency_key, request_hash)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (principal, idempotency_key) DO UPDATE
+			SET request_hash = EXCLUDED.request_hash, message_id = NULL, created_at = now()
+			WHERE idempotency_keys.created_at < now() - make_interval(secs => $4)
+		RETURNING true`,
+		key.Principal, key.Key, key.RequestHash, key.Retention.Seconds(),
+	).Scan(&claimed)
+	if errors.Is(err, sql.ErrNoRows) {
+		// Ключ уже закреплен за сообщением
+		if err := tx.Rollback(); err != nil {
+			return 0, "", false, err
+		}
+		id, status, err := lookupIdempotencyKey(ctx, db, key)
+		return id, status, err == nil, err
+	}
+	if err != nil {
+		return 0, "", false, err
+	}
+
+	args, err := insertMessageArgs(msg)
+	if err != nil {
+		return 0, "", false, err
+	}
+	if err := tx.QueryRowContext(ctx, insertMessageQuery+", status", args...).Scan(&id, &status); err != nil {
+		return 0, "", false, err
+	}
+	_, err = tx.ExecContext(ctx, "UPDATE idempotency_keys SET message_id = $1 WHERE principal = $2 AND idempotency_key = $3",
+		id, key.Principal, key.Key)
+	if err != nil {
+		return 0, "", false, err
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, "", false, err
+	}
+	return id, status, false, nil
+}
+
+// lookupIdempotencyKey возвращает ID и статус сообщения, закрепленного за ключом, или ErrIdempotencyKeyReused,
+// если ключ использован для запроса с другим содержимым.
+func lookupIdempotencyKey(ctx context.Context, db *sql.DB, key IdempotencyKey) (int, string, error) {
+	var requestHash string
+	var messageID sql.NullInt64
+	var status sql.NullString
+	err := db.QueryRowContext(ctx,
+		`SELECT k.request_hash, k.message_id, m.status FROM idempotency_keys k
+		LEFT JOIN messages m ON m.id = k.message_id
+		WHERE k.principal = $1 AND k.idempotency_key = $2`,
+		key.Principal, key.Key,
+	).Scan(&requestHash, &messageID, &status)
+	if err != nil {
+		return 0, "", err
+	}
+	if requestHash != key.RequestHash {
+		return 0, "", ErrIdempotencyKeyReused
+	}
+	if !messageID.Valid {
+		return 0, "", ErrMessageNotFound
+	}
+	return int(messageID.Int64), status.String, nil
+}
+
+// DeleteExpiredIdempotencyKeys удаляет ключи идемпотентности старше retention и возвращает их количество
+func DeleteExpiredIdempotencyKeys(ctx context.Context, db *sql.DB, retention time.Duration) (int64, error) {
+	res, err := db.ExecContext(ctx, "DELETE FROM idempotency_keys WHERE created_at < now() - make_interval(secs => $1)",
+		retention.Seconds())
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
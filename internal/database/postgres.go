@@ -3,19 +3,36 @@ package database
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
+	"errors"
 	"fmt"
-	_ "github.com/lib/pq"
+	"github.com/lib/pq"
 	"go_micro_gRPS/config"
+	"go_micro_gRPS/internal/models"
 	"log"
+	"strings"
+	"time"
 )
 
-func ConnectPostgres(ctx context.Context) (*sql.DB, error) {
-	cfg := config.LoadConfig()
+// ErrMessageNotFound сообщение с указанным ID отсутствует в БД
+var ErrMessageNotFound = errors.New("сообщение не найдено")
 
+// ErrMessageNotRetryable сообщение нельзя отправить повторно: его статус не 'failed' или истек срок жизни
+var ErrMessageNotRetryable = errors.New("сообщение нельзя отправить повторно")
+
+// ConnectPostgres подключается к PostgreSQL с настройками пула соединений из cfg и применяет миграции
+func ConnectPostgres(ctx context.Context, cfg config.Config) (*sql.DB, error) {
 	db, err := sql.Open("postgres", cfg.ConnStr)
 	if err != nil {
 		return nil, err
 	}
+
+	// Настройки пула соединений (0 - без ограничения)
+	db.SetMaxOpenConns(cfg.DBMaxOpenConns)
+	db.SetMaxIdleConns(cfg.DBMaxIdleConns)
+	db.SetConnMaxLifetime(cfg.DBConnMaxLifetime)
+	db.SetConnMaxIdleTime(cfg.DBConnMaxIdleTime)
+
 	if err = db.PingContext(ctx); err != nil {
 		//if err = db.Ping(); err != nil {
 		return nil, err
@@ -23,7 +40,7 @@ func ConnectPostgres(ctx context.Context) (*sql.DB, error) {
 	log.Println("Connected to PostgreSQL")
 
 	// Применение миграции для создания таблицы
-	err = applyMigrations(db)
+	err = applyMigrations(ctx, db, cfg.KafkaTopic)
 	if err != nil {
 		return nil, err
 	}
@@ -31,36 +48,292 @@ func ConnectPostgres(ctx context.Context) (*sql.DB, error) {
 	return db, nil
 }
 
-func applyMigrations(db *sql.DB) error {
-	query := `
-	CREATE TABLE IF NOT EXISTS messages (
-		id SERIAL PRIMARY KEY,
-		content TEXT NOT NULL,
-		status VARCHAR(20) DEFAULT 'pending'
-	);`
+// applyMigrations создаёт и обновляет схему. Сообщениям, сохраненным до появления столбца topic,
+// однократно назначается defaultTopic, в который они и были отправлены.
+func applyMigrations(ctx context.Context, db *sql.DB, defaultTopic string) error {
+	queries := []string{
+		`CREATE TABLE IF NOT EXISTS messages (
+			id SERIAL PRIMARY KEY,
+			content TEXT NOT NULL,
+			status VARCHAR(20) DEFAULT 'pending'
+		);`,
+		// Время создания сообщения для фильтрации списка сообщений
+		`ALTER TABLE messages ADD COLUMN IF NOT EXISTS created_at TIMESTAMPTZ NOT NULL DEFAULT now();`,
+		`CREATE INDEX IF NOT EXISTS messages_status_id_idx ON messages (status, id);`,
+		`CREATE INDEX IF NOT EXISTS messages_created_at_idx ON messages (created_at);`,
+		// Аутентифицированный отправитель сообщения
+		`ALTER TABLE messages ADD COLUMN IF NOT EXISTS created_by TEXT NOT NULL DEFAULT '';`,
+		// Ключ записи Kafka, атрибуты (заголовки Kafka) и MIME-тип содержимого
+		`ALTER TABLE messages ADD COLUMN IF NOT EXISTS message_key TEXT NOT NULL DEFAULT '';`,
+		`ALTER TABLE messages ADD COLUMN IF NOT EXISTS attributes JSONB NOT NULL DEFAULT '{}';`,
+		`ALTER TABLE messages ADD COLUMN IF NOT EXISTS content_type TEXT NOT NULL DEFAULT '';`,
+		// Ключи идемпотентности отправки: повтор запроса с тем же ключом возвращает исходное сообщение
+		`CREATE TABLE IF NOT EXISTS idempotency_keys (
+			principal TEXT NOT NULL,
+			idempotency_key TEXT NOT NULL,
+			request_hash TEXT NOT NULL,
+			message_id INTEGER REFERENCES messages (id) ON DELETE CASCADE,
+			created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+			PRIMARY KEY (principal, idempotency_key)
+		);`,
+		`CREATE INDEX IF NOT EXISTS idempotency_keys_created_at_idx ON idempotency_keys (created_at);`,
+		// Время обработки сообщения для статистики скорости обработки и задержки
+		`ALTER TABLE messages ADD COLUMN IF NOT EXISTS processed_at TIMESTAMPTZ;`,
+		`CREATE INDEX IF NOT EXISTS messages_processed_at_idx ON messages (processed_at);`,
+		// Отложенная доставка: планировщик выбирает сообщения со статусом 'scheduled' по deliver_at
+		`ALTER TABLE messages ADD COLUMN IF NOT EXISTS deliver_at TIMESTAMPTZ;`,
+		`CREATE INDEX IF NOT EXISTS messages_scheduled_idx ON messages (deliver_at) WHERE status = 'scheduled';`,
+		// Срок жизни: необработанные сообщения переводятся в статус 'expired' по expires_at
+		`ALTER TABLE messages ADD COLUMN IF NOT EXISTS expires_at TIMESTAMPTZ;`,
+		// Индекс включает и 'failed': такие сообщения могут быть отправлены повторно до истечения срока
+		`DROP INDEX IF EXISTS messages_expires_at_idx;`,
+		`CREATE INDEX IF NOT EXISTS messages_unsent_expires_at_idx ON messages (expires_at) WHERE status IN ('pending', 'scheduled', 'failed');`,
+		// Топик Kafka сообщения для фильтрации списка и статистики. Столбец добавляется со значением
+		// по умолчанию defaultTopic, которым PostgreSQL заполняет существующие строки при добавлении;
+		// при следующих запусках столбец уже есть и строки не обновляются
+		`ALTER TABLE messages ADD COLUMN IF NOT EXISTS topic TEXT NOT NULL DEFAULT ` + pq.QuoteLiteral(defaultTopic) + `;`,
+		`ALTER TABLE messages ALTER COLUMN topic SET DEFAULT '';`,
+		`CREATE INDEX IF NOT EXISTS messages_topic_id_idx ON messages (topic, id);`,
+		// Приоритет (полоса Kafka) сообщения
+		`ALTER TABLE messages ADD COLUMN IF NOT EXISTS priority TEXT NOT NULL DEFAULT 'normal';`,
+		`CREATE INDEX IF NOT EXISTS messages_priority_id_idx ON messages (priority, id);`,
+	}
 
-	_, err := db.Exec(query)
-	if err != nil {
-		return fmt.Errorf("Ошибка миграции базы данных: %v", err)
+	for _, query := range queries {
+		if _, err := db.ExecContext(ctx, query); err != nil {
+			return fmt.Errorf("Ошибка миграции базы данных: %v", err)
+		}
 	}
 
 	log.Println("Миграции успешно применены")
 	return nil
 }
 
-func SaveMessage(db *sql.DB, content string) (int, error) {
+// insertMessageQuery запрос сохранения нового сообщения (см. insertMessageArgs)
+const insertMessageQuery = `INSERT INTO messages (content, status, created_by, message_key, attributes, content_type, deliver_at, expires_at, topic, priority)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10) RETURNING id`
+
+// messageColumns столбцы, из которых читается models.Message (см. scanMessage)
+const messageColumns = "id, content, status, created_at, created_by, message_key, attributes, content_type, deliver_at, expires_at, topic, priority"
+
+// SaveMessage сохраняет сообщение и возвращает его ID. Статус по умолчанию - 'pending', приоритет - 'normal';
+// сообщения со статусом 'scheduled' отправляет планировщик по наступлении deliver_at.
+func SaveMessage(ctx context.Context, db *sql.DB, msg models.Message) (int, error) {
+	args, err := insertMessageArgs(msg)
+	if err != nil {
+		return 0, err
+	}
 	var id int
-	err := db.QueryRow("INSERT INTO messages (content, status) VALUES ($1, 'pending') RETURNING id", content).Scan(&id)
+	err = db.QueryRowContext(ctx, insertMessageQuery, args...).Scan(&id)
 	return id, err
 }
 
-func UpdateMessageStatus(db *sql.DB, id int, status string) error {
-	_, err := db.Exec("UPDATE messages SET status = $1 WHERE id = $2", status, id)
+// insertMessageArgs возвращает параметры insertMessageQuery для сообщения
+func insertMessageArgs(msg models.Message) ([]interface{}, error) {
+	attributes, err := encodeAttributes(msg.Attributes)
+	if err != nil {
+		return nil, err
+	}
+	status := msg.Status
+	if status == "" {
+		status = "pending"
+	}
+	priority := msg.Priority
+	if priority == "" {
+		priority = models.PriorityNormal
+	}
+	return []interface{}{msg.Content, status, msg.CreatedBy, msg.Key, attributes, msg.ContentType,
+		nullTime(msg.DeliverAt), nullTime(msg.ExpiresAt), msg.Topic, priority}, nil
+}
+
+// SaveMessages сохраняет пакет сообщений в одной транзакции и возвращает их ID в том же порядке
+func SaveMessages(ctx context.Context, db *sql.DB, messages []models.Message) ([]int, error) {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.PrepareContext(ctx, insertMessageQuery)
+	if err != nil {
+		return nil, err
+	}
+	defer stmt.Close()
+
+	ids := make([]int, len(messages))
+	for i, msg := range messages {
+		args, err := insertMessageArgs(msg)
+		if err != nil {
+			return nil, err
+		}
+		if err := stmt.QueryRowContext(ctx, args...).Scan(&ids[i]); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
+// setStatusClause устанавливает статус $1 и, при переходе в 'processed', время обработки
+const setStatusClause = `SET status = $1,
+	processed_at = CASE WHEN $1 = 'processed' THEN now() ELSE processed_at END`
+
+// UpdateMessageStatus устанавливает статус сообщения
+func UpdateMessageStatus(ctx context.Context, db *sql.DB, id int, status string) error {
+	_, err := db.ExecContext(ctx, "UPDATE messages "+setStatusClause+" WHERE id = $2", status, id)
+	return err
+}
+
+// UpdateMessagesStatus устанавливает статус сразу для нескольких сообщений
+func UpdateMessagesStatus(ctx context.Context, db *sql.DB, ids []int, status string) error {
+	_, err := db.ExecContext(ctx, "UPDATE messages "+setStatusClause+" WHERE id = ANY($2)", status, pq.Array(ids))
+	return err
+}
+
+// CompleteDeliveries устанавливает статус ('processed' или 'failed') асинхронно отправленным сообщениям
+// по отчёту о доставке. Обновляются только сообщения в статусе 'pending': сообщение, срок жизни которого
+// истек до подтверждения Kafka, остается в статусе 'expired'.
+func CompleteDeliveries(ctx context.Context, db *sql.DB, ids []int, status string) error {
+	_, err := db.ExecContext(ctx, "UPDATE messages "+setStatusClause+" WHERE id = ANY($2) AND status = 'pending'",
+		status, pq.Array(ids))
 	return err
 }
 
-func GetProcessedMessageCount(db *sql.DB) (int, error) {
+// ClaimFailedMessage переводит сообщение со статусом 'failed' и неистекшим сроком жизни в статус 'pending'
+// для повторной отправки и возвращает его. Условное обновление гарантирует, что из одновременных
+// повторов сообщение получит только один. Возвращает ErrMessageNotFound или ErrMessageNotRetryable.
+func ClaimFailedMessage(ctx context.Context, db *sql.DB, id int) (*models.Message, error) {
+	msg, err := scanMessage(db.QueryRowContext(ctx, `UPDATE messages SET status = 'pending'
+		WHERE id = $1 AND status = 'failed' AND (expires_at IS NULL OR expires_at > now())
+		RETURNING `+messageColumns, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		if _, err := GetMessage(ctx, db, id); err != nil {
+			return nil, err
+		}
+		return nil, ErrMessageNotRetryable
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &msg, nil
+}
+
+// GetProcessedMessageCount возвращает количество обработанных сообщений
+func GetProcessedMessageCount(ctx context.Context, db *sql.DB) (int, error) {
 	var count int
-	err := db.QueryRow("SELECT COUNT(*) FROM messages WHERE status = 'processed'").Scan(&count)
+	err := db.QueryRowContext(ctx, "SELECT COUNT(*) FROM messages WHERE status = 'processed'").Scan(&count)
 	return count, err
 }
+
+// MessageFilter параметры выборки списка сообщений
+type MessageFilter struct {
+	Statuses      []string  // Допустимые статусы (пусто - любые)
+	Topics        []string  // Допустимые топики (пусто - любые)
+	Priorities    []string  // Допустимые приоритеты (пусто - любые)
+	CreatedAfter  time.Time // Нижняя граница времени создания, включительно (нулевое значение - без ограничения)
+	CreatedBefore time.Time // Верхняя граница времени создания, не включительно (нулевое значение - без ограничения)
+	BeforeID      int       // Курсор: выбираются сообщения с ID меньше указанного (0 - с начала)
+	Limit         int       // Максимальное количество сообщений
+}
+
+// GetMessage возвращает сообщение по ID или ErrMessageNotFound
+func GetMessage(ctx context.Context, db *sql.DB, id int) (*models.Message, error) {
+	msg, err := scanMessage(db.QueryRowContext(ctx, "SELECT "+messageColumns+" FROM messages WHERE id = $1", id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrMessageNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &msg, nil
+}
+
+// ListMessages возвращает сообщения, удовлетворяющие фильтру, в порядке убывания ID
+func ListMessages(ctx context.Context, db *sql.DB, filter MessageFilter) ([]models.Message, error) {
+	var conditions []string
+	var args []interface{}
+	addCondition := func(cond string, arg interface{}) {
+		args = append(args, arg)
+		conditions = append(conditions, fmt.Sprintf(cond, len(args)))
+	}
+
+	if len(filter.Statuses) > 0 {
+		addCondition("status = ANY($%d)", pq.Array(filter.Statuses))
+	}
+	if len(filter.Topics) > 0 {
+		addCondition("topic = ANY($%d)", pq.Array(filter.Topics))
+	}
+	if len(filter.Priorities) > 0 {
+		addCondition("priority = ANY($%d)", pq.Array(filter.Priorities))
+	}
+	if !filter.CreatedAfter.IsZero() {
+		addCondition("created_at >= $%d", filter.CreatedAfter)
+	}
+	if !filter.CreatedBefore.IsZero() {
+		addCondition("created_at < $%d", filter.CreatedBefore)
+	}
+	if filter.BeforeID > 0 {
+		addCondition("id < $%d", filter.BeforeID)
+	}
+
+	query := "SELECT " + messageColumns + " FROM messages"
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	args = append(args, filter.Limit)
+	query += fmt.Sprintf(" ORDER BY id DESC LIMIT $%d", len(args))
+
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var messages []models.Message
+	for rows.Next() {
+		msg, err := scanMessage(rows)
+		if err != nil {
+			return nil, err
+		}
+		messages = append(messages, msg)
+	}
+	return messages, rows.Err()
+}
+
+// scanMessage читает сообщение из строки результата со столбцами messageColumns
+func scanMessage(row interface{ Scan(...interface{}) error }) (models.Message, error) {
+	var msg models.Message
+	var attributes []byte
+	var deliverAt, expiresAt sql.NullTime
+	err := row.Scan(&msg.ID, &msg.Content, &msg.Status, &msg.CreatedAt, &msg.CreatedBy,
+		&msg.Key, &attributes, &msg.ContentType, &deliverAt, &expiresAt, &msg.Topic, &msg.Priority)
+	if err != nil {
+		return msg, err
+	}
+	msg.DeliverAt = deliverAt.Time
+	msg.ExpiresAt = expiresAt.Time
+	if err := json.Unmarshal(attributes, &msg.Attributes); err != nil {
+		return msg, fmt.Errorf("ошибка разбора атрибутов сообщения %d: %v", msg.ID, err)
+	}
+	if len(msg.Attributes) == 0 {
+		msg.Attributes = nil
+	}
+	return msg, nil
+}
+
+// nullTime преобразует нулевое время в NULL
+func nullTime(t time.Time) sql.NullTime {
+	return sql.NullTime{Time: t, Valid: !t.IsZero()}
+}
+
+// encodeAttributes сериализует атрибуты сообщения для столбца JSONB
+func encodeAttributes(attributes map[string]string) (string, error) {
+	if attributes == nil {
+		attributes = map[string]string{}
+	}
+	data, err := json.Marshal(attributes)
+	return string(data), err
+}
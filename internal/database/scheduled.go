@@ -0,0 +1,99 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"go_micro_gRPS/internal/models"
+	"strings"
+
+	"github.com/lib/pq"
+)
+
+// priorityOrder выражение сортировки по приоритету в порядке models.Priorities; неизвестные
+// приоритеты сортируются последними
+var priorityOrder = func() string {
+	var b strings.Builder
+	b.WriteString("CASE priority")
+	for i, priority := range models.Priorities {
+		fmt.Fprintf(&b, " WHEN %s THEN %d", pq.QuoteLiteral(priority), i)
+	}
+	fmt.Fprintf(&b, " ELSE %d END", len(models.Priorities))
+	return b.String()
+}()
+
+// DeliverDueMessages выбирает до limit отложенных сообщений, время отправки которых наступило,
+// а срок жизни не истек, и передаёт их в send. Сообщения с более высоким приоритетом выбираются первыми.
+// send возвращает ошибку отправки для каждого сообщения (nil - отправлено);
+// по ним сообщения получают статус 'processed' или 'failed'.
+//
+// Строки блокируются (FOR UPDATE SKIP LOCKED) до конца транзакции, включая отправку, поэтому
+// несколько экземпляров сервиса могут опрашивать таблицу одновременно без повторной отправки.
+// Возвращает количество выбранных сообщений.
+func DeliverDueMessages(ctx context.Context, db *sql.DB, limit int, send func([]models.Message) []error) (int, error) {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.QueryContext(ctx, "SELECT "+messageColumns+` FROM messages
+		WHERE status = 'scheduled' AND deliver_at <= now() AND (expires_at IS NULL OR expires_at > now())
+		ORDER BY `+priorityOrder+`, deliver_at
+		LIMIT $1
+		FOR UPDATE SKIP LOCKED`, limit)
+	if err != nil {
+		return 0, err
+	}
+	var messages []models.Message
+	for rows.Next() {
+		msg, err := scanMessage(rows)
+		if err != nil {
+			rows.Close()
+			return 0, err
+		}
+		messages = append(messages, msg)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+	if len(messages) == 0 {
+		return 0, nil
+	}
+
+	errs := send(messages)
+	var processed, failed []int
+	for i, msg := range messages {
+		if errs[i] != nil {
+			failed = append(failed, msg.ID)
+			continue
+		}
+		processed = append(processed, msg.ID)
+	}
+	for status, ids := range map[string][]int{"processed": processed, "failed": failed} {
+		if len(ids) == 0 {
+			continue
+		}
+		if _, err := tx.ExecContext(ctx, "UPDATE messages "+setStatusClause+" WHERE id = ANY($2)", status, pq.Array(ids)); err != nil {
+			return 0, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	return len(messages), nil
+}
+
+// ExpireMessages переводит в статус 'expired' неотправленные ('pending', 'scheduled', 'failed') сообщения
+// с истекшим сроком жизни и возвращает их количество. Истекшее сообщение со статусом 'failed'
+// больше нельзя отправить повторно через RetryMessage.
+func ExpireMessages(ctx context.Context, db *sql.DB) (int64, error) {
+	res, err := db.ExecContext(ctx, `UPDATE messages SET status = 'expired'
+		WHERE status IN ('pending', 'scheduled', 'failed') AND expires_at <= now()`)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
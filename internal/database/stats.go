@@ -0,0 +1,154 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"go_micro_gRPS/internal/models"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// StatsWindows скользящие окна, за которые считается скорость приема и обработки сообщений (по возрастанию)
+var StatsWindows = []time.Duration{time.Minute, 5 * time.Minute, time.Hour}
+
+// Statuses статусы сообщений, которые всегда присутствуют в статистике, даже с нулевым количеством
+var Statuses = []string{"pending", "scheduled", "processed", "failed", "expired"}
+
+// LatencyWindow окно, за которое считаются перцентили задержки обработки
+const LatencyWindow = time.Hour
+
+// Stats статистика сообщений
+type Stats struct {
+	StatusCounts   map[string]int64 // Количество сообщений по статусам
+	PriorityCounts map[string]int64 // Количество сообщений по приоритетам
+	Throughput     []Throughput     // Скорость приема и обработки по окнам StatsWindows
+	Latency        Latency          // Задержка от создания до обработки за LatencyWindow
+}
+
+// Throughput количество созданных и обработанных сообщений за окно
+type Throughput struct {
+	Window    time.Duration
+	Ingested  int64
+	Processed int64
+}
+
+// Latency распределение задержки от создания до обработки сообщений
+// (для отложенных сообщений - от запланированного времени отправки)
+type Latency struct {
+	Count                   int64
+	Avg, P50, P90, P95, P99 time.Duration
+	Max                     time.Duration
+}
+
+// GetStats возвращает количество сообщений по статусам и приоритетам, скорость приема и обработки
+// в окнах StatsWindows и перцентили задержки обработки за LatencyWindow.
+// Непустой topic ограничивает статистику сообщениями этого топика.
+func GetStats(ctx context.Context, db *sql.DB, topic string) (*Stats, error) {
+	stats := &Stats{}
+
+	// Количество сообщений по статусам и приоритетам
+	var err error
+	stats.StatusCounts, err = countBy(ctx, db, "status", topic, Statuses)
+	if err != nil {
+		return nil, err
+	}
+	stats.PriorityCounts, err = countBy(ctx, db, "priority", topic, models.Priorities)
+	if err != nil {
+		return nil, err
+	}
+
+	// Скорость приема и обработки: оба подсчета используют индексы по created_at и processed_at
+	stats.Throughput = make([]Throughput, len(StatsWindows))
+	ingested, err := countInWindows(ctx, db, "created_at", topic)
+	if err != nil {
+		return nil, err
+	}
+	processed, err := countInWindows(ctx, db, "processed_at", topic)
+	if err != nil {
+		return nil, err
+	}
+	for i, window := range StatsWindows {
+		stats.Throughput[i] = Throughput{Window: window, Ingested: ingested[i], Processed: processed[i]}
+	}
+
+	// Перцентили задержки от создания до обработки
+	var count int64
+	var avg, max sql.NullFloat64
+	var percentiles pq.Float64Array
+	err = db.QueryRowContext(ctx, `SELECT COUNT(*), AVG(latency), MAX(latency),
+			percentile_cont(ARRAY[0.5, 0.9, 0.95, 0.99]) WITHIN GROUP (ORDER BY latency)
+		FROM (
+			SELECT EXTRACT(EPOCH FROM processed_at - GREATEST(created_at, deliver_at)) AS latency
+			FROM messages
+			WHERE processed_at >= now() - make_interval(secs => $1) AND ($2 = '' OR topic = $2)
+		) AS processed`,
+		LatencyWindow.Seconds(), topic,
+	).Scan(&count, &avg, &max, &percentiles)
+	if err != nil {
+		return nil, err
+	}
+	stats.Latency = Latency{Count: count, Avg: seconds(avg.Float64), Max: seconds(max.Float64)}
+	if len(percentiles) == 4 {
+		stats.Latency.P50 = seconds(percentiles[0])
+		stats.Latency.P90 = seconds(percentiles[1])
+		stats.Latency.P95 = seconds(percentiles[2])
+		stats.Latency.P99 = seconds(percentiles[3])
+	}
+	return stats, nil
+}
+
+// countBy подсчитывает сообщения топика topic (пусто - всех топиков) по значениям column;
+// значения keys присутствуют в результате и при нулевом количестве
+func countBy(ctx context.Context, db *sql.DB, column, topic string, keys []string) (map[string]int64, error) {
+	counts := make(map[string]int64, len(keys))
+	for _, key := range keys {
+		counts[key] = 0
+	}
+
+	rows, err := db.QueryContext(ctx, fmt.Sprintf("SELECT %s, COUNT(*) FROM messages WHERE ($1 = '' OR topic = $1) GROUP BY %s", column, column), topic)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var value sql.NullString
+		var count int64
+		if err := rows.Scan(&value, &count); err != nil {
+			return nil, err
+		}
+		counts[value.String] += count
+	}
+	return counts, rows.Err()
+}
+
+// countInWindows подсчитывает сообщения топика topic (пусто - всех топиков),
+// у которых column попадает в каждое из окон StatsWindows
+func countInWindows(ctx context.Context, db *sql.DB, column, topic string) ([]int64, error) {
+	filters := make([]string, len(StatsWindows))
+	args := make([]interface{}, len(StatsWindows), len(StatsWindows)+1)
+	for i, window := range StatsWindows {
+		args[i] = window.Seconds()
+		filters[i] = fmt.Sprintf("COUNT(*) FILTER (WHERE %s >= now() - make_interval(secs => $%d))", column, i+1)
+	}
+	args = append(args, topic)
+	// Внешнее условие по последнему, самому широкому окну ограничивает выборку индексом
+	query := fmt.Sprintf("SELECT %s FROM messages WHERE %s >= now() - make_interval(secs => $%d) AND ($%d = '' OR topic = $%d)",
+		strings.Join(filters, ", "), column, len(StatsWindows), len(args), len(args))
+
+	counts := make([]int64, len(StatsWindows))
+	dest := make([]interface{}, len(counts))
+	for i := range counts {
+		dest[i] = &counts[i]
+	}
+	if err := db.QueryRowContext(ctx, query, args...).Scan(dest...); err != nil {
+		return nil, err
+	}
+	return counts, nil
+}
+
+func seconds(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
@@ -2,68 +2,326 @@ package kafka_services
 
 import (
 	"context"
+	"errors"
+	"go_micro_gRPS/internal/models"
 	"log"
 	"sync"
+	"time"
 
 	"github.com/segmentio/kafka-go"
 )
 
+const (
+	// historySize количество последних сообщений, которые хранятся для воспроизведения подписчикам.
+	historySize = 1000
+	// defaultSubscriberBuffer размер буфера подписчика по умолчанию.
+	defaultSubscriberBuffer = 256
+	// MaxSubscriberBuffer максимальный размер буфера подписчика.
+	MaxSubscriberBuffer = 10000
+	// laneBufferSize количество сообщений, прочитанных из полосы приоритета заранее.
+	// Небольшой буфер ограничивает объем, на который низкий приоритет может опередить высокий.
+	laneBufferSize = 16
+)
+
 // Consumer представляет Kafka consumer с буфером сообщений.
+// Полосы приоритета читаются отдельными reader'ами; при наличии сообщений в нескольких полосах
+// первым обрабатывается сообщение с более высоким приоритетом.
 type Consumer struct {
-	reader   *kafka.Reader
+	lanes    []*lane // В порядке убывания приоритета (models.Priorities)
 	messages []Message
 	mu       sync.Mutex
+
+	// Рассылка прочитанных сообщений подписчикам
+	subMu       sync.Mutex
+	subscribers map[*Subscription]struct{}
+	history     []Message
+	closed      bool
 }
 
 // Message структура для хранения сообщений.
 type Message struct {
-	Key   string `json:"key"`
-	Value string `json:"value"`
+	Topic     string            `json:"topic"`
+	Partition int               `json:"partition"`
+	Offset    int64             `json:"offset"`
+	Key       string            `json:"key"`
+	Value     string            `json:"value"`
+	Time      time.Time         `json:"time"`
+	Headers   map[string]string `json:"headers,omitempty"`
+	Priority  string            `json:"priority"` // Приоритет полосы, из которой прочитано сообщение
+}
+
+// lane reader полосы одного приоритета.
+type lane struct {
+	priority string
+	reader   *kafka.Reader
+}
+
+// Expired сообщает, истек ли к моменту now срок жизни сообщения из заголовка expires-at.
+// Сообщения без заголовка или с некорректным значением не истекают.
+func (m Message) Expired(now time.Time) bool {
+	value, ok := m.Headers[models.HeaderExpiresAt]
+	if !ok {
+		return false
+	}
+	expiresAt, err := time.Parse(time.RFC3339Nano, value)
+	if err != nil {
+		log.Printf("Некорректный заголовок %s=%q в сообщении offset=%d", models.HeaderExpiresAt, value, m.Offset)
+		return false
+	}
+	return !now.Before(expiresAt)
 }
 
-// NewKafkaConsumer создаёт consumer с буфером сообщений.
-func NewKafkaConsumer(brokers []string, topic, groupID string) *Consumer {
-	return &Consumer{
-		reader: kafka.NewReader(kafka.ReaderConfig{
-			Brokers:     brokers,
-			Topic:       topic,
-			GroupID:     groupID,
-			MinBytes:    10e3,
-			MaxBytes:    10e6,
-			StartOffset: kafka.LastOffset,
-		}),
-		messages: make([]Message, 0),
+// NewKafkaConsumer создаёт consumer группы groupID, подписанный на один или несколько топиков, с буфером сообщений.
+// Для каждого приоритета создаётся отдельный reader, читающий полосы этого приоритета всех топиков (см. LaneTopic).
+func NewKafkaConsumer(brokers []string, topics []string, groupID string) *Consumer {
+	c := &Consumer{
+		messages:    make([]Message, 0),
+		subscribers: make(map[*Subscription]struct{}),
+	}
+	for _, priority := range models.Priorities {
+		laneTopics := make([]string, len(topics))
+		for i, topic := range topics {
+			laneTopics[i] = LaneTopic(topic, priority)
+		}
+		c.lanes = append(c.lanes, &lane{
+			priority: priority,
+			reader: kafka.NewReader(kafka.ReaderConfig{
+				Brokers:     brokers,
+				GroupTopics: laneTopics,
+				GroupID:     groupID,
+				MinBytes:    10e3,
+				MaxBytes:    10e6,
+				StartOffset: kafka.LastOffset,
+			}),
+		})
 	}
+	return c
 }
 
-// ReadMessages читает сообщения и сохраняет их в буфер.
+// ReadMessages читает сообщения всех полос, сохраняет их в буфер и рассылает подписчикам.
+// Чтение останавливается при отмене контекста или первой ошибке чтения любой из полос.
 func (c *Consumer) ReadMessages(ctx context.Context) {
+	defer c.closeSubscribers()
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// Полосы читаются параллельно; ready сигнализирует о появлении сообщения в любой из них
+	queues := make([]chan Message, len(c.lanes))
+	ready := make(chan struct{}, 1)
+	errs := make(chan error, len(c.lanes))
+	for i, l := range c.lanes {
+		queues[i] = make(chan Message, laneBufferSize)
+		go l.read(ctx, queues[i], ready, errs)
+	}
+
 	for {
-		msg, err := c.reader.ReadMessage(ctx)
+		m, err := nextMessage(ctx, queues, ready, errs)
 		if err != nil {
 			log.Printf("Ошибка чтения: %v", err)
 			return
 		}
 
+		// Сообщения с истекшим сроком жизни пропускаются без обращения к БД
+		if m.Expired(time.Now()) {
+			log.Printf("Пропущено сообщение с истекшим сроком жизни: Key=%s, offset=%d", m.Key, m.Offset)
+			continue
+		}
+
 		c.mu.Lock()
-		c.messages = append(c.messages, Message{Key: string(msg.Key), Value: string(msg.Value)})
+		c.messages = append(c.messages, m)
 		c.mu.Unlock()
 
-		log.Printf("Получено сообщение: Key=%s, Value=%s", msg.Key, msg.Value)
+		c.broadcast(m)
+
+		log.Printf("Получено сообщение: Topic=%s, Key=%s, Value=%s", m.Topic, m.Key, m.Value)
 	}
 }
 
-// GetMessages возвращает буфер сообщений и очищает его.
+// nextMessage возвращает сообщение из полосы с наивысшим приоритетом, в которой оно есть,
+// или ждет появления сообщения в любой полосе.
+func nextMessage(ctx context.Context, queues []chan Message, ready <-chan struct{}, errs <-chan error) (Message, error) {
+	for {
+		for _, queue := range queues {
+			select {
+			case m := <-queue:
+				return m, nil
+			default:
+			}
+		}
+
+		select {
+		case <-ready:
+		case err := <-errs:
+			return Message{}, err
+		case <-ctx.Done():
+			return Message{}, ctx.Err()
+		}
+	}
+}
+
+// read читает сообщения полосы в queue, пока не будет отменен контекст или не произойдет ошибка.
+func (l *lane) read(ctx context.Context, queue chan<- Message, ready chan<- struct{}, errs chan<- error) {
+	for {
+		msg, err := l.reader.ReadMessage(ctx)
+		if err != nil {
+			errs <- err
+			return
+		}
+
+		m := Message{
+			Topic:     msg.Topic,
+			Partition: msg.Partition,
+			Offset:    msg.Offset,
+			Key:       string(msg.Key),
+			Value:     string(msg.Value),
+			Time:      msg.Time,
+			Headers:   headersMap(msg.Headers),
+			Priority:  l.priority,
+		}
+		select {
+		case queue <- m:
+		case <-ctx.Done():
+			return
+		}
+		select {
+		case ready <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// GetMessages возвращает буфер сообщений и очищает его. Сообщения, срок жизни которых
+// истек за время нахождения в буфере, не возвращаются.
 func (c *Consumer) GetMessages() []Message {
 	c.mu.Lock()
-	defer c.mu.Unlock()
-
 	messages := c.messages
 	c.messages = nil
-	return messages
+	c.mu.Unlock()
+
+	return dropExpired(messages, time.Now())
+}
+
+// ReplayPosition позиция в партиции топика, начиная с которой подписчику воспроизводится история.
+type ReplayPosition struct {
+	Topic     string
+	Partition int
+	Offset    int64
+}
+
+// Subscribe регистрирует нового подписчика на поток прочитанных сообщений.
+// Если передана позиция from, подписчик сначала получает сообщения из истории
+// той же партиции того же топика с offset >= from.Offset.
+// bufferSize <= 0 означает размер буфера по умолчанию, больший MaxSubscriberBuffer уменьшается до него.
+func (c *Consumer) Subscribe(from *ReplayPosition, bufferSize int) (*Subscription, error) {
+	if bufferSize <= 0 {
+		bufferSize = defaultSubscriberBuffer
+	}
+	bufferSize = min(bufferSize, MaxSubscriberBuffer)
+
+	c.subMu.Lock()
+	defer c.subMu.Unlock()
+
+	if c.closed {
+		return nil, ErrConsumerClosed
+	}
+
+	var replay []Message
+	if from != nil {
+		now := time.Now()
+		for _, m := range c.history {
+			if m.Topic == from.Topic && m.Partition == from.Partition && m.Offset >= from.Offset && !m.Expired(now) {
+				replay = append(replay, m)
+			}
+		}
+	}
+
+	// Буфер увеличивается на размер воспроизводимой истории (не больше historySize), чтобы она поместилась целиком
+	sub := &Subscription{ch: make(chan Message, bufferSize+len(replay))}
+	for _, m := range replay {
+		sub.ch <- m
+	}
+	c.subscribers[sub] = struct{}{}
+	return sub, nil
+}
+
+// Unsubscribe отписывает подписчика и закрывает его канал.
+func (c *Consumer) Unsubscribe(sub *Subscription) {
+	c.subMu.Lock()
+	defer c.subMu.Unlock()
+
+	if _, ok := c.subscribers[sub]; ok {
+		delete(c.subscribers, sub)
+		close(sub.ch)
+	}
 }
 
-// Close закрывает Kafka consumer.
+// broadcast рассылает сообщение всем подписчикам и сохраняет его в истории.
+// Медленные подписчики, чей буфер переполнен, отключаются, чтобы не задерживать чтение из Kafka.
+func (c *Consumer) broadcast(m Message) {
+	c.subMu.Lock()
+	defer c.subMu.Unlock()
+
+	c.history = append(c.history, m)
+	if len(c.history) > historySize {
+		c.history = c.history[len(c.history)-historySize:]
+	}
+
+	for sub := range c.subscribers {
+		select {
+		case sub.ch <- m:
+		default:
+			log.Printf("Подписчик не успевает читать сообщения, подписка разорвана")
+			sub.err = ErrSlowSubscriber
+			delete(c.subscribers, sub)
+			close(sub.ch)
+		}
+	}
+}
+
+// closeSubscribers закрывает все подписки после остановки чтения из Kafka.
+func (c *Consumer) closeSubscribers() {
+	c.subMu.Lock()
+	defer c.subMu.Unlock()
+
+	c.closed = true
+	for sub := range c.subscribers {
+		sub.err = ErrConsumerClosed
+		delete(c.subscribers, sub)
+		close(sub.ch)
+	}
+}
+
+// Close закрывает reader'ы всех полос Kafka consumer.
 func (c *Consumer) Close() error {
-	return c.reader.Close()
+	var errs []error
+	for _, l := range c.lanes {
+		if err := l.reader.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// dropExpired удаляет из среза сообщения с истекшим сроком жизни.
+func dropExpired(messages []Message, now time.Time) []Message {
+	kept := messages[:0]
+	for _, m := range messages {
+		if !m.Expired(now) {
+			kept = append(kept, m)
+		}
+	}
+	return kept
+}
+
+// headersMap преобразует заголовки записи Kafka в map; при повторе имени остаётся последнее значение.
+func headersMap(headers []kafka.Header) map[string]string {
+	if len(headers) == 0 {
+		return nil
+	}
+	m := make(map[string]string, len(headers))
+	for _, h := range headers {
+		m[h.Key] = string(h.Value)
+	}
+	return m
 }
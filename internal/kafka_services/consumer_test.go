@@ -0,0 +1,312 @@
+package kafka_services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"go_micro_gRPS/internal/models"
+)
+
+// expiringMessage сообщение со смещением offset и заголовком expires-at (пусто - без заголовка).
+func expiringMessage(offset int64, expiresAt string) Message {
+	m := Message{Topic: "messages", Offset: offset}
+	if expiresAt != "" {
+		m.Headers = map[string]string{models.HeaderExpiresAt: expiresAt}
+	}
+	return m
+}
+
+func TestMessageExpired(t *testing.T) {
+	now := time.Date(2030, 1, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name      string
+		expiresAt string
+		want      bool
+	}{
+		{"без заголовка", "", false},
+		{"срок в будущем", now.Add(time.Second).Format(time.RFC3339Nano), false},
+		{"срок истекает сейчас", now.Format(time.RFC3339Nano), true},
+		{"срок в прошлом", now.Add(-time.Nanosecond).Format(time.RFC3339Nano), true},
+		{"срок в другом часовом поясе", now.In(time.FixedZone("UTC+3", 3*3600)).Add(-time.Minute).Format(time.RFC3339Nano), true},
+		{"некорректное значение", "tomorrow", false},
+		{"время без часового пояса", "2020-01-01T00:00:00", false},
+	}
+	for _, tt := range tests {
+		if got := expiringMessage(1, tt.expiresAt).Expired(now); got != tt.want {
+			t.Errorf("%s: Expired = %v, ожидалось %v", tt.name, got, tt.want)
+		}
+	}
+	// Пустое значение заголовка некорректно, сообщение не истекает
+	if (Message{Headers: map[string]string{models.HeaderExpiresAt: ""}}).Expired(now) {
+		t.Error("пустой заголовок: сообщение не должно истекать")
+	}
+}
+
+func TestDropExpired(t *testing.T) {
+	now := time.Date(2030, 1, 1, 12, 0, 0, 0, time.UTC)
+	past := now.Add(-time.Minute).Format(time.RFC3339Nano)
+	future := now.Add(time.Minute).Format(time.RFC3339Nano)
+
+	messages := []Message{
+		expiringMessage(1, past),
+		expiringMessage(2, ""),
+		expiringMessage(3, future),
+		expiringMessage(4, past),
+		expiringMessage(5, "not-a-time"),
+		expiringMessage(6, past),
+	}
+	kept := dropExpired(messages, now)
+	want := []int64{2, 3, 5}
+	if len(kept) != len(want) {
+		t.Fatalf("осталось %d сообщений, ожидалось %d", len(kept), len(want))
+	}
+	for i, m := range kept {
+		if m.Offset != want[i] {
+			t.Errorf("сообщение %d: offset %d, ожидался %d", i, m.Offset, want[i])
+		}
+	}
+
+	if kept := dropExpired(nil, now); len(kept) != 0 {
+		t.Errorf("пустой список: осталось %d сообщений", len(kept))
+	}
+}
+
+// priorityQueues очереди полос в порядке models.Priorities, как в ReadMessages.
+func priorityQueues() []chan Message {
+	queues := make([]chan Message, len(models.Priorities))
+	for i := range queues {
+		queues[i] = make(chan Message, laneBufferSize)
+	}
+	return queues
+}
+
+func TestNextMessageDrainsHighPriorityFirst(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	queues := priorityQueues()
+	high, low := queues[0], queues[len(queues)-1]
+
+	// Низкоприоритетные сообщения поступили раньше высокоприоритетных
+	for i := int64(0); i < 5; i++ {
+		low <- Message{Offset: 100 + i, Priority: models.PriorityLow}
+	}
+	for i := int64(0); i < 3; i++ {
+		high <- Message{Offset: i, Priority: models.PriorityHigh}
+	}
+
+	// Внутри полосы порядок сохраняется, полосы выбираются по убыванию приоритета
+	want := []int64{0, 1, 2, 100, 101, 102, 103, 104}
+	for i, offset := range want {
+		m, err := nextMessage(ctx, queues, make(chan struct{}), make(chan error))
+		if err != nil {
+			t.Fatalf("сообщение %d: %v", i, err)
+		}
+		if m.Offset != offset {
+			t.Fatalf("сообщение %d: offset %d (%s), ожидался %d", i, m.Offset, m.Priority, offset)
+		}
+		// Высокоприоритетное сообщение, поступившее во время разбора низкой полосы, выбирается следующим
+		if offset == 101 {
+			high <- Message{Offset: 3, Priority: models.PriorityHigh}
+			if m, _ := nextMessage(ctx, queues, nil, nil); m.Offset != 3 {
+				t.Fatalf("после offset 101 выбран offset %d, ожидался 3 из высокой полосы", m.Offset)
+			}
+		}
+	}
+}
+
+func TestNextMessageWaits(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	queues := priorityQueues()
+	ready := make(chan struct{}, 1)
+	errs := make(chan error, 1)
+
+	// Пустые полосы: сообщение возвращается после сигнала ready
+	go func() {
+		queues[1] <- Message{Offset: 7}
+		ready <- struct{}{}
+	}()
+	if m, err := nextMessage(ctx, queues, ready, errs); err != nil || m.Offset != 7 {
+		t.Errorf("ожидание сообщения: offset %d, ошибка %v", m.Offset, err)
+	}
+
+	readErr := errors.New("reader closed")
+	errs <- readErr
+	if _, err := nextMessage(ctx, queues, ready, errs); !errors.Is(err, readErr) {
+		t.Errorf("ошибка чтения полосы: %v", err)
+	}
+
+	canceled, cancelNow := context.WithCancel(ctx)
+	cancelNow()
+	if _, err := nextMessage(canceled, queues, ready, errs); !errors.Is(err, context.Canceled) {
+		t.Errorf("отмененный контекст: %v", err)
+	}
+}
+
+func newTestConsumer() *Consumer {
+	return &Consumer{subscribers: make(map[*Subscription]struct{})}
+}
+
+// drain читает сообщения подписки до закрытия канала или опустошения буфера и возвращает их смещения.
+func drain(sub *Subscription) (offsets []int64, closed bool) {
+	for {
+		select {
+		case m, ok := <-sub.Messages():
+			if !ok {
+				return offsets, true
+			}
+			offsets = append(offsets, m.Offset)
+		default:
+			return offsets, false
+		}
+	}
+}
+
+func equalOffsets(a, b []int64) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestSubscribeReplay(t *testing.T) {
+	c := newTestConsumer()
+	past := time.Now().Add(-time.Minute).Format(time.RFC3339Nano)
+	history := []Message{
+		{Topic: "messages", Partition: 0, Offset: 1},
+		{Topic: "messages", Partition: 0, Offset: 2},
+		{Topic: "messages", Partition: 1, Offset: 2},
+		{Topic: "audit", Partition: 0, Offset: 3},
+		{Topic: "messages", Partition: 0, Offset: 3, Headers: map[string]string{models.HeaderExpiresAt: past}},
+		{Topic: "messages", Partition: 0, Offset: 4},
+	}
+	for _, m := range history {
+		c.broadcast(m)
+	}
+
+	tests := []struct {
+		name string
+		from *ReplayPosition
+		want []int64
+	}{
+		{"без позиции", nil, nil},
+		{"с начала партиции", &ReplayPosition{Topic: "messages", Partition: 0, Offset: 0}, []int64{1, 2, 4}},
+		{"с offset включительно, без истекших", &ReplayPosition{Topic: "messages", Partition: 0, Offset: 2}, []int64{2, 4}},
+		{"другая партиция", &ReplayPosition{Topic: "messages", Partition: 1, Offset: 0}, []int64{2}},
+		{"другой топик", &ReplayPosition{Topic: "audit", Partition: 0, Offset: 0}, []int64{3}},
+		{"позиция после истории", &ReplayPosition{Topic: "messages", Partition: 0, Offset: 5}, nil},
+		{"неизвестный топик", &ReplayPosition{Topic: "orders", Partition: 0, Offset: 0}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sub, err := c.Subscribe(tt.from, 1)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer c.Unsubscribe(sub)
+			if got, _ := drain(sub); !equalOffsets(got, tt.want) {
+				t.Errorf("воспроизведены offset %v, ожидались %v", got, tt.want)
+			}
+			// После истории подписчик получает новые сообщения: буфер увеличен на размер истории
+			c.broadcast(Message{Topic: "other", Offset: 99})
+			if got, closed := drain(sub); closed || !equalOffsets(got, []int64{99}) {
+				t.Errorf("после истории получены offset %v (канал закрыт: %v), ожидался 99", got, closed)
+			}
+		})
+	}
+}
+
+func TestSubscribeReplayLimitedByHistory(t *testing.T) {
+	c := newTestConsumer()
+	const extra = 10
+	for i := int64(0); i < historySize+extra; i++ {
+		c.broadcast(Message{Topic: "messages", Offset: i})
+	}
+	sub, err := c.Subscribe(&ReplayPosition{Topic: "messages"}, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, _ := drain(sub)
+	if len(got) != historySize || got[0] != extra {
+		t.Errorf("воспроизведено %d сообщений начиная с offset %v, ожидалось %d с offset %d", len(got), got[:min(1, len(got))], historySize, extra)
+	}
+}
+
+func TestSlowSubscriberDropped(t *testing.T) {
+	c := newTestConsumer()
+	slow, err := c.Subscribe(nil, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fast, err := c.Subscribe(nil, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := int64(0); i < 3; i++ {
+		c.broadcast(Message{Offset: i})
+	}
+
+	// Медленный подписчик получает то, что поместилось в буфер, и подписка закрывается
+	got, closed := drain(slow)
+	if !closed || !equalOffsets(got, []int64{0, 1}) {
+		t.Errorf("медленный подписчик: offset %v, канал закрыт: %v", got, closed)
+	}
+	if !errors.Is(slow.Err(), ErrSlowSubscriber) {
+		t.Errorf("медленный подписчик: Err = %v, ожидалась ErrSlowSubscriber", slow.Err())
+	}
+	// Остальные подписчики продолжают получать сообщения
+	if got, closed := drain(fast); closed || !equalOffsets(got, []int64{0, 1, 2}) {
+		t.Errorf("быстрый подписчик: offset %v, канал закрыт: %v", got, closed)
+	}
+	// Повторная отписка отключенного подписчика не закрывает канал второй раз
+	c.Unsubscribe(slow)
+}
+
+func TestSubscriberBufferSize(t *testing.T) {
+	c := newTestConsumer()
+	for _, tt := range []struct{ requested, want int }{
+		{0, defaultSubscriberBuffer},
+		{-1, defaultSubscriberBuffer},
+		{5, 5},
+		{MaxSubscriberBuffer + 1, MaxSubscriberBuffer},
+	} {
+		sub, err := c.Subscribe(nil, tt.requested)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if cap(sub.ch) != tt.want {
+			t.Errorf("буфер %d: размер %d, ожидался %d", tt.requested, cap(sub.ch), tt.want)
+		}
+	}
+}
+
+func TestCloseSubscribers(t *testing.T) {
+	c := newTestConsumer()
+	sub, err := c.Subscribe(nil, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.broadcast(Message{Offset: 1})
+	c.closeSubscribers()
+
+	// Сообщения, полученные до остановки, остаются в канале
+	got, closed := drain(sub)
+	if !closed || !equalOffsets(got, []int64{1}) {
+		t.Errorf("после остановки: offset %v, канал закрыт: %v", got, closed)
+	}
+	if !errors.Is(sub.Err(), ErrConsumerClosed) {
+		t.Errorf("Err = %v, ожидалась ErrConsumerClosed", sub.Err())
+	}
+	if _, err := c.Subscribe(&ReplayPosition{}, 10); !errors.Is(err, ErrConsumerClosed) {
+		t.Errorf("Subscribe после остановки: %v, ожидалась ErrConsumerClosed", err)
+	}
+	c.Unsubscribe(sub)
+}
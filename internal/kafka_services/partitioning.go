@@ -0,0 +1,118 @@
+package kafka_services
+
+import (
+	"fmt"
+	"github.com/segmentio/kafka-go"
+	"math/rand/v2"
+	"strconv"
+	"strings"
+)
+
+// Выбор партиции записи складывается из стратегии ключа (какой ключ получает запись)
+// и балансировщика (как ключ отображается на партицию).
+//
+// Гарантии порядка. Kafka сохраняет порядок записей только внутри партиции, поэтому записи
+// с одинаковым ключом читаются consumer'ом в порядке отправки, если:
+//   - балансировщик учитывает ключ (hash, murmur2, crc32); round_robin и least_bytes распределяют
+//     записи без учета ключа, и порядок между партициями не определен;
+//   - число партиций топика не меняется: после добавления партиций ключ может перейти в другую;
+//   - записи отправлены в одну полосу приоритета: полосы - разные топики (см. LaneTopic);
+//   - записи отправлены одним producer'ом последовательно и одним способом: порядок одновременных
+//     вызовов из разных запросов определяется очередностью их записи, а синхронная отправка
+//     (SendMessage, SendMessages) и асинхронная (Publish) идут через разные writer'ы;
+//   - при асинхронной отправке повтор пакета после временной ошибки Kafka может поставить его
+//     после более позднего пакета той же партиции; если порядок важен, используйте синхронную отправку.
+//
+// Стратегия id дает каждому сообщению свой ключ: порядок между сообщениями не сохраняется,
+// но повторная отправка сообщения (RetryMessage) попадает в ту же партицию. Стратегия random
+// не дает гарантий порядка. Записи без ключа (client без ключа в запросе, attribute без атрибута)
+// распределяются балансировщиком hash по кругу, murmur2 и crc32 - в случайную партицию.
+
+// Стратегии ключа записи
+const (
+	KeyByClient    = "client"    // Ключ из запроса клиента (по умолчанию); без ключа - запись без ключа
+	KeyByID        = "id"        // ID сообщения в БД
+	KeyByAttribute = "attribute" // Значение атрибута сообщения: "attribute:<имя>", например attribute:tenant
+	KeyRandom      = "random"    // Случайный ключ для каждой записи
+)
+
+// Балансировщики партиций
+const (
+	BalancerHash       = "hash"        // FNV-1a хеш ключа (по умолчанию); записи без ключа - по кругу
+	BalancerMurmur2    = "murmur2"     // murmur2 хеш ключа, те же партиции, что у Java-клиента Kafka
+	BalancerCRC32      = "crc32"       // CRC32 хеш ключа, те же партиции, что у librdkafka по умолчанию
+	BalancerRoundRobin = "round_robin" // По кругу без учета ключа
+	BalancerLeastBytes = "least_bytes" // В партицию с наименьшим объемом отправленных данных без учета ключа
+)
+
+// KeyStrategy возвращает ключ записи Kafka для сообщения (nil - запись без ключа).
+type KeyStrategy func(m KeyedMessage) []byte
+
+// ClientKey использует ключ, переданный клиентом в запросе.
+func ClientKey(m KeyedMessage) []byte {
+	if m.Key == "" {
+		return nil
+	}
+	return []byte(m.Key)
+}
+
+// MessageIDKey использует ID сообщения в БД.
+func MessageIDKey(m KeyedMessage) []byte {
+	return []byte(strconv.Itoa(m.ID))
+}
+
+// AttributeKey использует значение атрибута name (например, идентификатор арендатора),
+// чтобы все сообщения с одним значением попадали в одну партицию.
+func AttributeKey(name string) KeyStrategy {
+	return func(m KeyedMessage) []byte {
+		value, ok := m.Headers[name]
+		if !ok || value == "" {
+			return nil
+		}
+		return []byte(value)
+	}
+}
+
+// RandomKey использует случайный ключ для каждой записи.
+func RandomKey(KeyedMessage) []byte {
+	return []byte(strconv.FormatUint(rand.Uint64(), 16))
+}
+
+// ParseKeyStrategy возвращает стратегию ключа по названию: client (или пусто), id, random, attribute:<имя>.
+func ParseKeyStrategy(name string) (KeyStrategy, error) {
+	if attribute, ok := strings.CutPrefix(name, KeyByAttribute+":"); ok {
+		if attribute == "" {
+			return nil, fmt.Errorf("не задано имя атрибута для стратегии ключа %q", name)
+		}
+		return AttributeKey(attribute), nil
+	}
+	switch name {
+	case "", KeyByClient:
+		return ClientKey, nil
+	case KeyByID:
+		return MessageIDKey, nil
+	case KeyRandom:
+		return RandomKey, nil
+	default:
+		return nil, fmt.Errorf("неизвестная стратегия ключа %q: допустимы client, id, random, attribute:<имя>", name)
+	}
+}
+
+// ParseBalancer возвращает балансировщик партиций по названию: hash (или пусто), murmur2, crc32,
+// round_robin, least_bytes.
+func ParseBalancer(name string) (kafka.Balancer, error) {
+	switch name {
+	case "", BalancerHash:
+		return &kafka.Hash{}, nil
+	case BalancerMurmur2:
+		return kafka.Murmur2Balancer{}, nil
+	case BalancerCRC32:
+		return kafka.CRC32Balancer{}, nil
+	case BalancerRoundRobin:
+		return &kafka.RoundRobin{}, nil
+	case BalancerLeastBytes:
+		return &kafka.LeastBytes{}, nil
+	default:
+		return nil, fmt.Errorf("неизвестный балансировщик %q: допустимы hash, murmur2, crc32, round_robin, least_bytes", name)
+	}
+}
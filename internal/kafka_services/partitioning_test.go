@@ -0,0 +1,137 @@
+package kafka_services
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// testPartitions партиции топика, между которыми выбирают балансировщики в тестах
+var testPartitions = []int{0, 1, 2, 3, 4, 5, 6, 7}
+
+// partitionOf возвращает партицию записи для сообщения m при стратегии ключа strategy и балансировщике balancer.
+func partitionOf(t *testing.T, strategy, balancer string, m KeyedMessage) int {
+	t.Helper()
+	keyFor, err := ParseKeyStrategy(strategy)
+	if err != nil {
+		t.Fatalf("ParseKeyStrategy(%q): %v", strategy, err)
+	}
+	b, err := ParseBalancer(balancer)
+	if err != nil {
+		t.Fatalf("ParseBalancer(%q): %v", balancer, err)
+	}
+	return b.Balance(kafka.Message{Key: keyFor(m), Value: []byte("value")}, testPartitions...)
+}
+
+func TestKeyedBalancersKeepKeyInPartition(t *testing.T) {
+	// Пары сообщений, которые стратегия должна отправлять с одним ключом: они различаются
+	// всем, кроме источника ключа стратегии
+	strategies := []struct {
+		strategy string
+		a, b     KeyedMessage
+	}{
+		{KeyByClient, KeyedMessage{ID: 1, Key: "order-42"}, KeyedMessage{ID: 2, Key: "order-42", Headers: map[string]string{"tenant": "x"}}},
+		{KeyByID, KeyedMessage{ID: 7, Key: "a"}, KeyedMessage{ID: 7, Key: "b"}},
+		{KeyByAttribute + ":tenant", KeyedMessage{ID: 1, Key: "a", Headers: map[string]string{"tenant": "acme"}}, KeyedMessage{ID: 2, Key: "b", Headers: map[string]string{"tenant": "acme"}}},
+	}
+	balancers := []string{"", BalancerHash, BalancerMurmur2, BalancerCRC32}
+
+	for _, s := range strategies {
+		for _, balancer := range balancers {
+			t.Run(s.strategy+"/"+balancer, func(t *testing.T) {
+				want := partitionOf(t, s.strategy, balancer, s.a)
+				// Повторные вызовы на новом балансировщике и на одном и том же дают ту же партицию
+				for i := 0; i < 10; i++ {
+					if got := partitionOf(t, s.strategy, balancer, s.b); got != want {
+						t.Fatalf("партиция %d, ожидалась %d", got, want)
+					}
+				}
+				keyFor, _ := ParseKeyStrategy(s.strategy)
+				b, _ := ParseBalancer(balancer)
+				for i := 0; i < 10; i++ {
+					m := s.a
+					if i%2 == 1 {
+						m = s.b
+					}
+					if got := b.Balance(kafka.Message{Key: keyFor(m)}, testPartitions...); got != want {
+						t.Fatalf("вызов %d: партиция %d, ожидалась %d", i, got, want)
+					}
+				}
+			})
+		}
+	}
+}
+
+func TestKeyedBalancersSpreadKeys(t *testing.T) {
+	for _, balancer := range []string{BalancerHash, BalancerMurmur2, BalancerCRC32} {
+		t.Run(balancer, func(t *testing.T) {
+			used := make(map[int]bool)
+			for i := 0; i < 100; i++ {
+				used[partitionOf(t, KeyByClient, balancer, KeyedMessage{Key: fmt.Sprintf("key-%d", i)})] = true
+			}
+			if len(used) < 2 {
+				t.Errorf("100 разных ключей попали в %d партицию", len(used))
+			}
+		})
+	}
+}
+
+func TestUnkeyedBalancersIgnoreKey(t *testing.T) {
+	// round_robin и least_bytes не учитывают ключ: записи с одним ключом расходятся по партициям
+	for _, balancer := range []string{BalancerRoundRobin, BalancerLeastBytes} {
+		t.Run(balancer, func(t *testing.T) {
+			b, err := ParseBalancer(balancer)
+			if err != nil {
+				t.Fatal(err)
+			}
+			used := make(map[int]bool)
+			for i := 0; i < len(testPartitions); i++ {
+				used[b.Balance(kafka.Message{Key: []byte("order-42"), Value: []byte("value")}, testPartitions...)] = true
+			}
+			if len(used) < 2 {
+				t.Errorf("записи с одним ключом попали в одну партицию, балансировщик не должен учитывать ключ")
+			}
+		})
+	}
+}
+
+func TestRandomKeyDiffers(t *testing.T) {
+	m := KeyedMessage{ID: 1, Key: "order-42"}
+	if string(RandomKey(m)) == string(RandomKey(m)) {
+		t.Error("стратегия random вернула одинаковые ключи для одного сообщения")
+	}
+}
+
+func TestMissingKey(t *testing.T) {
+	tests := []struct {
+		strategy string
+		m        KeyedMessage
+	}{
+		{KeyByClient, KeyedMessage{ID: 1}},
+		{KeyByAttribute + ":tenant", KeyedMessage{ID: 1, Headers: map[string]string{"region": "eu"}}},
+		{KeyByAttribute + ":tenant", KeyedMessage{ID: 1, Headers: map[string]string{"tenant": ""}}},
+	}
+	for _, tt := range tests {
+		keyFor, err := ParseKeyStrategy(tt.strategy)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if key := keyFor(tt.m); key != nil {
+			t.Errorf("%s: ключ %q, ожидалась запись без ключа", tt.strategy, key)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, name := range []string{"attribute:", "attribute", "partition"} {
+		if _, err := ParseKeyStrategy(name); err == nil {
+			t.Errorf("ParseKeyStrategy(%q): ожидалась ошибка", name)
+		}
+	}
+	for _, name := range []string{"sticky", "Hash"} {
+		if _, err := ParseBalancer(name); err == nil {
+			t.Errorf("ParseBalancer(%q): ожидалась ошибка", name)
+		}
+	}
+}
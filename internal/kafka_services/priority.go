@@ -0,0 +1,23 @@
+package kafka_services
+
+import "go_micro_gRPS/internal/models"
+
+// LaneTopic возвращает топик полосы приоритета priority (см. models.Priorities) для топика topic.
+// Полоса normal совпадает с самим топиком, остальные получают суффикс: "orders.high", "orders.low".
+func LaneTopic(topic, priority string) string {
+	if priority == "" || priority == models.PriorityNormal {
+		return topic
+	}
+	return topic + "." + priority
+}
+
+// LaneTopics возвращает топики всех полос приоритета для каждого из topics.
+func LaneTopics(topics []string) []string {
+	lanes := make([]string, 0, len(topics)*len(models.Priorities))
+	for _, topic := range topics {
+		for _, priority := range models.Priorities {
+			lanes = append(lanes, LaneTopic(topic, priority))
+		}
+	}
+	return lanes
+}
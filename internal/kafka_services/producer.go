@@ -4,54 +4,116 @@ package kafka_services
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"github.com/segmentio/kafka-go"
 	"log"
+	"sort"
+	"time"
 )
 
+// ErrAsyncDisabled возвращается Publish, если producer создан без асинхронного режима
+var ErrAsyncDisabled = errors.New("асинхронная отправка не включена")
+
+// KeyedMessage сообщение с ключом и заголовками для отправки в Kafka
+type KeyedMessage struct {
+	ID       int               // ID сообщения в БД, передаётся в отчёт о доставке асинхронной отправки
+	Topic    string            // Топик записи (пусто - топик producer'а по умолчанию)
+	Priority string            // Приоритет: запись отправляется в полосу топика этого приоритета (см. LaneTopic)
+	Key      string            // Ключ клиента; ключ записи определяет стратегия ключа producer'а
+	Headers  map[string]string // Заголовки записи
+	Message  interface{}       // Значение записи, сериализуется в JSON
+}
+
+// ProducerConfig параметры пакетной отправки producer'а.
+type ProducerConfig struct {
+	BatchSize    int           // Максимальное количество записей в пакете (0 - 100)
+	BatchTimeout time.Duration // Максимальное время накопления неполного пакета (0 - 1 секунда)
+	Compression  string        // Сжатие пакетов: none, gzip, snappy, lz4, zstd (пусто - без сжатия)
+
+	KeyStrategy KeyStrategy    // Ключ записи (nil - ClientKey), см. ParseKeyStrategy
+	Balancer    kafka.Balancer // Выбор партиции по ключу (nil - hash), см. ParseBalancer
+
+	// Async включает асинхронную отправку Publish: записи накапливаются в пакеты без ожидания
+	// вызывающей стороной, а результат доставки каждой записи передаётся в OnDelivery.
+	// Синхронные SendMessage и SendMessages работают и в этом режиме.
+	Async      bool
+	OnDelivery func(reports []DeliveryReport)
+}
+
+// DeliveryReport результат асинхронной отправки записи.
+type DeliveryReport struct {
+	ID  int   // KeyedMessage.ID
+	Err error // nil - запись подтверждена Kafka, иначе ошибка после исчерпания попыток
+}
+
 // Producer KafkaProducer представляет собой структуру для работы с Kafka producer
 type Producer struct {
-	writer *kafka.Writer
+	writer       *kafka.Writer
+	asyncWriter  *kafka.Writer // nil без асинхронного режима
+	defaultTopic string
+	keyStrategy  KeyStrategy
 }
 
-// NewKafkaProducer инициализирует новый Kafka producer и управляет его жизненным циклом
-func NewKafkaProducer(ctx context.Context, brokers []string, topic string) *Producer {
-	// Инициализация продюсера напрямую через структуру kafka_services.Writer
-	producer := &Producer{
-		writer: &kafka.Writer{
-			Addr:        kafka.TCP(brokers...), // Адреса брокеров Kafka
-			Topic:       topic,                 // Название топика
-			Balancer:    &kafka.LeastBytes{},   // Балансировщик LeastBytes для равномерного распределения
-			MaxAttempts: 3,                     // Максимальное количество попыток отправки
-			Async:       false,                 // Синхронный режим для последовательной отправки
-		},
-	}
-
-	go func() {
-		<-ctx.Done()
-		log.Println("Shutting down Kafka producer...")
-		// producer.Close()
-		if err := producer.Close(); err != nil {
-			log.Printf("Ошибка при закрытии Kafka producer: %v", err)
+// NewKafkaProducer инициализирует новый Kafka producer; его закрывает вызывающий через Close.
+// Writer не привязан к топику: топик задаётся для каждой записи, defaultTopic используется для записей без топика.
+func NewKafkaProducer(brokers []string, defaultTopic string, cfg ProducerConfig) (*Producer, error) {
+	var compression kafka.Compression
+	if cfg.Compression != "" {
+		if err := compression.UnmarshalText([]byte(cfg.Compression)); err != nil {
+			return nil, fmt.Errorf("некорректный кодек сжатия: %v", err)
+		}
+	}
+	if cfg.KeyStrategy == nil {
+		cfg.KeyStrategy = ClientKey
+	}
+	if cfg.Balancer == nil {
+		cfg.Balancer = &kafka.Hash{}
+	}
+	if cfg.Async && cfg.OnDelivery == nil {
+		return nil, fmt.Errorf("для асинхронной отправки нужен обработчик отчётов о доставке")
+	}
+
+	newWriter := func() *kafka.Writer {
+		return &kafka.Writer{
+			Addr:         kafka.TCP(brokers...), // Адреса брокеров Kafka
+			Balancer:     cfg.Balancer,          // Партиция по ключу записи (см. partitioning.go)
+			MaxAttempts:  3,                     // Максимальное количество попыток отправки
+			BatchSize:    cfg.BatchSize,
+			BatchTimeout: cfg.BatchTimeout,
+			Compression:  compression,
 		}
-	}()
+	}
 
-	return producer
+	// Синхронный writer: WriteMessages возвращается после подтверждения Kafka
+	producer := &Producer{defaultTopic: defaultTopic, keyStrategy: cfg.KeyStrategy, writer: newWriter()}
+	if cfg.Async {
+		producer.asyncWriter = newWriter()
+		producer.asyncWriter.Async = true
+		producer.asyncWriter.Completion = func(messages []kafka.Message, err error) {
+			cfg.OnDelivery(deliveryReports(messages, err))
+		}
+	}
+
+	return producer, nil
+}
+
+// Async сообщает, включена ли асинхронная отправка Publish.
+func (kp *Producer) Async() bool {
+	return kp.asyncWriter != nil
 }
 
 // SendMessage отправляет сообщение в Kafka
-func (kp *Producer) SendMessage(ctx context.Context, key string, message interface{}) error {
+func (kp *Producer) SendMessage(ctx context.Context, message KeyedMessage) error {
 	// Преобразуем сообщение в JSON
-	msg, err := json.Marshal(message)
+	msg, err := kp.toKafkaMessage(message)
 	if err != nil {
 		log.Printf("Ошибка сериализации сообщения: %v", err)
 		return err
 	}
 
 	// Отправляем сообщение в Kafka
-	err = kp.writer.WriteMessages(ctx, kafka.Message{
-		Key:   []byte(key),
-		Value: msg,
-	})
+	err = kp.writer.WriteMessages(ctx, msg)
 
 	if err != nil {
 		log.Printf("Ошибка отправки сообщения в Kafka: %v", err)
@@ -62,7 +124,119 @@ func (kp *Producer) SendMessage(ctx context.Context, key string, message interfa
 	return nil
 }
 
-// Close закрывает Kafka writer
+// SendMessages отправляет пакет сообщений в Kafka одним вызовом WriteMessages.
+// При частичной ошибке ошибки по отдельным сообщениям можно получить через MessageErrors.
+func (kp *Producer) SendMessages(ctx context.Context, messages []KeyedMessage) error {
+	if len(messages) == 0 {
+		return nil
+	}
+
+	// Преобразуем сообщения в JSON
+	batch := make([]kafka.Message, 0, len(messages))
+	for _, m := range messages {
+		msg, err := kp.toKafkaMessage(m)
+		if err != nil {
+			log.Printf("Ошибка сериализации сообщения: %v", err)
+			return err
+		}
+		batch = append(batch, msg)
+	}
+
+	// Отправляем пакет сообщений в Kafka
+	if err := kp.writer.WriteMessages(ctx, batch...); err != nil {
+		log.Printf("Ошибка отправки пакета сообщений в Kafka: %v", err)
+		return err
+	}
+
+	log.Printf("Пакет из %d сообщений успешно отправлен в Kafka", len(batch))
+	return nil
+}
+
+// Publish ставит сообщение в очередь асинхронной отправки и возвращается, не дожидаясь Kafka.
+// Результат доставки передаётся в ProducerConfig.OnDelivery с ID сообщения. Ошибка возвращается,
+// только если сообщение не удалось поставить в очередь.
+func (kp *Producer) Publish(ctx context.Context, message KeyedMessage) error {
+	if kp.asyncWriter == nil {
+		return ErrAsyncDisabled
+	}
+	msg, err := kp.toKafkaMessage(message)
+	if err != nil {
+		log.Printf("Ошибка сериализации сообщения: %v", err)
+		return err
+	}
+	if err := kp.asyncWriter.WriteMessages(ctx, msg); err != nil {
+		log.Printf("Ошибка постановки сообщения в очередь отправки в Kafka: %v", err)
+		return err
+	}
+	return nil
+}
+
+// deliveryReports формирует отчёты о доставке пакета записей асинхронного writer'а:
+// ошибка пакета относится ко всем его записям.
+func deliveryReports(messages []kafka.Message, err error) []DeliveryReport {
+	reports := make([]DeliveryReport, len(messages))
+	for i, msg := range messages {
+		id, _ := msg.WriterData.(int)
+		reports[i] = DeliveryReport{ID: id, Err: err}
+	}
+	return reports
+}
+
+// toKafkaMessage сериализует значение в JSON и переносит топик полосы приоритета, ключ по стратегии ключа
+// и заголовки в запись Kafka. Отсутствующий ключ передаётся как nil, чтобы балансировщик не отправлял
+// все такие записи в одну партицию.
+func (kp *Producer) toKafkaMessage(m KeyedMessage) (kafka.Message, error) {
+	value, err := json.Marshal(m.Message)
+	if err != nil {
+		return kafka.Message{}, err
+	}
+
+	topic := m.Topic
+	if topic == "" {
+		topic = kp.defaultTopic
+	}
+	msg := kafka.Message{Topic: LaneTopic(topic, m.Priority), Key: kp.keyStrategy(m), Value: value, WriterData: m.ID}
+	if len(m.Headers) > 0 {
+		names := make([]string, 0, len(m.Headers))
+		for name := range m.Headers {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		msg.Headers = make([]kafka.Header, 0, len(names))
+		for _, name := range names {
+			msg.Headers = append(msg.Headers, kafka.Header{Key: name, Value: []byte(m.Headers[name])})
+		}
+	}
+	return msg, nil
+}
+
+// MessageErrors раскладывает ошибку SendMessages на ошибки по каждому из n сообщений пакета.
+// Если ошибка не относится к отдельным сообщениям, она возвращается для всех сообщений.
+func MessageErrors(err error, n int) []error {
+	errs := make([]error, n)
+	if err == nil {
+		return errs
+	}
+
+	var writeErrs kafka.WriteErrors
+	if errors.As(err, &writeErrs) && len(writeErrs) == n {
+		copy(errs, writeErrs)
+		return errs
+	}
+
+	for i := range errs {
+		errs[i] = err
+	}
+	return errs
+}
+
+// Close закрывает Kafka writer'ы. Асинхронный writer перед закрытием отправляет накопленные записи
+// и дожидается вызовов OnDelivery для них.
 func (kp *Producer) Close() error {
-	return kp.writer.Close()
+	var errs []error
+	if kp.asyncWriter != nil {
+		errs = append(errs, kp.asyncWriter.Close())
+	}
+	errs = append(errs, kp.writer.Close())
+	return errors.Join(errs...)
 }
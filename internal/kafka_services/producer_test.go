@@ -0,0 +1,45 @@
+package kafka_services
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/segmentio/kafka-go"
+)
+
+func TestDeliveryReports(t *testing.T) {
+	messages := []kafka.Message{{WriterData: 1}, {WriterData: 7}, {}}
+	for _, err := range []error{nil, errors.New("kafka unavailable")} {
+		reports := deliveryReports(messages, err)
+		// Запись без ID (не из Publish) получает ID 0
+		for i, want := range []int{1, 7, 0} {
+			if reports[i].ID != want || reports[i].Err != err {
+				t.Errorf("отчёт %d: %+v, ожидался ID %d с ошибкой %v", i, reports[i], want, err)
+			}
+		}
+	}
+	if reports := deliveryReports(nil, nil); len(reports) != 0 {
+		t.Errorf("отчёты пустого пакета: %v", reports)
+	}
+}
+
+func TestPublishWithoutAsync(t *testing.T) {
+	p, err := NewKafkaProducer([]string{"127.0.0.1:1"}, "messages", ProducerConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+	if p.Async() {
+		t.Error("producer без Async сообщает об асинхронной отправке")
+	}
+	if err := p.Publish(context.Background(), KeyedMessage{ID: 1, Message: "hello"}); !errors.Is(err, ErrAsyncDisabled) {
+		t.Errorf("Publish: %v, ожидалась ErrAsyncDisabled", err)
+	}
+}
+
+func TestAsyncRequiresOnDelivery(t *testing.T) {
+	if _, err := NewKafkaProducer([]string{"127.0.0.1:1"}, "messages", ProducerConfig{Async: true}); err == nil {
+		t.Error("асинхронный producer без OnDelivery: ожидалась ошибка")
+	}
+}
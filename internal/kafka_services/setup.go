@@ -1,16 +1,23 @@
 package kafka_services
 
 import (
+	"context"
 	"fmt"
 	"github.com/segmentio/kafka-go"
 	"log"
 	"net"
 	"strconv"
+	"time"
 )
 
+// topicSetupTimeout ограничение времени подключения к контроллеру и создания топика
+const topicSetupTimeout = 30 * time.Second
+
 // CreateKafkaTopic проверяет существование и создаёт топик, если его нет
 func CreateKafkaTopic(brokers []string, topic string, numPartitions, replicationFactor int) error {
-	controllerConn, err := getKafkaController(brokers)
+	ctx, cancel := context.WithTimeout(context.Background(), topicSetupTimeout)
+	defer cancel()
+	controllerConn, err := getKafkaController(ctx, brokers)
 	if err != nil {
 		return fmt.Errorf("ошибка получения контроллера Kafka: %v", err)
 	}
@@ -32,28 +39,59 @@ func CreateKafkaTopic(brokers []string, topic string, numPartitions, replication
 	return nil
 }
 
-// getKafkaController получает соединение с контроллером Kafka
-func getKafkaController(brokers []string) (*kafka.Conn, error) {
+// getKafkaController получает соединение с контроллером Kafka. Подключение к брокерам и запрос
+// контроллера ограничены сроком ctx; срок действует и для операций с возвращённым соединением.
+func getKafkaController(ctx context.Context, brokers []string) (*kafka.Conn, error) {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return nil, fmt.Errorf("для подключения к контроллеру Kafka не задан срок")
+	}
+	dialer := &kafka.Dialer{Timeout: time.Until(deadline)}
 	for _, broker := range brokers {
-		conn, err := kafka.Dial("tcp", broker)
-		if err == nil {
-			controller, err := conn.Controller()
-			if err != nil {
-				conn.Close()
-				return nil, fmt.Errorf("ошибка получения контроллера: %v", err)
-			}
-
-			controllerConn, err := kafka.Dial("tcp", net.JoinHostPort(controller.Host, strconv.Itoa(controller.Port)))
+		conn, err := dialer.DialContext(ctx, "tcp", broker)
+		if err != nil {
+			continue
+		}
+		if err := conn.SetDeadline(deadline); err != nil {
 			conn.Close()
-			if err != nil {
-				return nil, fmt.Errorf("ошибка подключения к контроллеру: %v", err)
-			}
-			return controllerConn, nil
+			return nil, fmt.Errorf("ошибка установки таймаута: %v", err)
+		}
+		controller, err := conn.Controller()
+		conn.Close()
+		if err != nil {
+			return nil, fmt.Errorf("ошибка получения контроллера: %v", err)
 		}
+
+		controllerConn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(controller.Host, strconv.Itoa(controller.Port)))
+		if err != nil {
+			return nil, fmt.Errorf("ошибка подключения к контроллеру: %v", err)
+		}
+		if err := controllerConn.SetDeadline(deadline); err != nil {
+			controllerConn.Close()
+			return nil, fmt.Errorf("ошибка установки таймаута: %v", err)
+		}
+		return controllerConn, nil
 	}
 	return nil, fmt.Errorf("не удалось подключиться ни к одному из брокеров: %v", brokers)
 }
 
+// CheckBrokers проверяет доступность кластера Kafka: подключается к контроллеру
+// и запрашивает метаданные брокеров. Вся проверка, включая подключение, ограничена таймаутом timeout.
+func CheckBrokers(brokers []string, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	controllerConn, err := getKafkaController(ctx, brokers)
+	if err != nil {
+		return fmt.Errorf("ошибка получения контроллера Kafka: %v", err)
+	}
+	defer controllerConn.Close()
+
+	if _, err := controllerConn.Brokers(); err != nil {
+		return fmt.Errorf("ошибка получения метаданных брокеров: %v", err)
+	}
+	return nil
+}
+
 // topicExists проверяет, существует ли топик в Kafka
 func topicExists(conn *kafka.Conn, topic string) (bool, error) {
 	partitions, err := conn.ReadPartitions(topic)
@@ -0,0 +1,27 @@
+package kafka_services
+
+import "errors"
+
+var (
+	// ErrSlowSubscriber подписка разорвана, так как подписчик не успевал читать сообщения.
+	ErrSlowSubscriber = errors.New("подписчик не успевает обрабатывать сообщения")
+	// ErrConsumerClosed чтение из Kafka остановлено, новых сообщений не будет.
+	ErrConsumerClosed = errors.New("kafka consumer остановлен")
+)
+
+// Subscription подписка на сообщения, прочитанные Consumer'ом.
+type Subscription struct {
+	ch  chan Message
+	err error
+}
+
+// Messages возвращает канал сообщений подписки. Канал закрывается при отписке,
+// отключении медленного подписчика или остановке consumer'а.
+func (s *Subscription) Messages() <-chan Message {
+	return s.ch
+}
+
+// Err возвращает причину закрытия подписки. Значение корректно только после закрытия канала Messages.
+func (s *Subscription) Err() error {
+	return s.err
+}
@@ -0,0 +1,77 @@
+// Package memconn реализует net.Listener в памяти для соединений внутри процесса
+// (HTTP-шлюз и внутренний gRPC-сервер). Соединения создаются через net.Pipe и недоступны извне.
+package memconn
+
+import (
+	"context"
+	"net"
+	"sync"
+)
+
+// Network сеть соединений Listener: по ней перехватчики отличают вызовы через HTTP-шлюз
+// (адрес клиента передаётся шлюзом в x-forwarded-for).
+const Network = "memconn"
+
+// addr адрес обоих концов соединений в памяти
+type addr struct{}
+
+func (addr) Network() string { return Network }
+func (addr) String() string  { return Network }
+
+// conn конец net.Pipe с адресами сети Network вместо "pipe".
+type conn struct {
+	net.Conn
+}
+
+func (conn) LocalAddr() net.Addr  { return addr{} }
+func (conn) RemoteAddr() net.Addr { return addr{} }
+
+// Listener принимает соединения, установленные через DialContext.
+type Listener struct {
+	conns     chan net.Conn
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// Listen создаёт Listener.
+func Listen() *Listener {
+	return &Listener{conns: make(chan net.Conn), done: make(chan struct{})}
+}
+
+// Accept ожидает следующее соединение.
+func (l *Listener) Accept() (net.Conn, error) {
+	select {
+	case c := <-l.conns:
+		return c, nil
+	case <-l.done:
+		return nil, net.ErrClosed
+	}
+}
+
+// Close закрывает Listener; уже установленные соединения не закрываются.
+func (l *Listener) Close() error {
+	l.closeOnce.Do(func() { close(l.done) })
+	return nil
+}
+
+// Addr возвращает адрес Listener.
+func (l *Listener) Addr() net.Addr {
+	return addr{}
+}
+
+// DialContext устанавливает соединение с Listener и ожидает его приёма не дольше срока ctx.
+func (l *Listener) DialContext(ctx context.Context) (net.Conn, error) {
+	server, client := net.Pipe()
+	var err error
+	select {
+	case l.conns <- conn{server}:
+		return conn{client}, nil
+	case <-l.done:
+		err = net.ErrClosed
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+	server.Close()
+	client.Close()
+	return nil, err
+}
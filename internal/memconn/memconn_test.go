@@ -0,0 +1,83 @@
+package memconn
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/peer"
+)
+
+// peerHealth health-сервер, запоминающий сеть клиента последнего вызова.
+type peerHealth struct {
+	*health.Server
+	network chan string
+}
+
+func (h *peerHealth) Check(ctx context.Context, req *healthpb.HealthCheckRequest) (*healthpb.HealthCheckResponse, error) {
+	if p, ok := peer.FromContext(ctx); ok {
+		h.network <- p.Addr.Network()
+	}
+	return h.Server.Check(ctx, req)
+}
+
+func TestGRPCOverListener(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	lis := Listen()
+	s := grpc.NewServer()
+	h := &peerHealth{Server: health.NewServer(), network: make(chan string, 1)}
+	healthpb.RegisterHealthServer(s, h)
+	go s.Serve(lis)
+	defer s.Stop()
+
+	conn, err := grpc.NewClient("passthrough:///memconn",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return lis.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	res, err := healthpb.NewHealthClient(conn).Check(ctx, &healthpb.HealthCheckRequest{})
+	if err != nil {
+		t.Fatalf("Check: %v", err)
+	}
+	if res.Status != healthpb.HealthCheckResponse_SERVING {
+		t.Errorf("статус %v", res.Status)
+	}
+	// Перехватчики определяют вызовы через шлюз по сети адреса клиента
+	if network := <-h.network; network != Network {
+		t.Errorf("сеть клиента %q, ожидалась %q", network, Network)
+	}
+}
+
+func TestDialClosedListener(t *testing.T) {
+	lis := Listen()
+	lis.Close()
+	if _, err := lis.DialContext(context.Background()); !errors.Is(err, net.ErrClosed) {
+		t.Errorf("DialContext после Close: %v", err)
+	}
+	if _, err := lis.Accept(); !errors.Is(err, net.ErrClosed) {
+		t.Errorf("Accept после Close: %v", err)
+	}
+}
+
+func TestDialCanceled(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	// Соединение никто не принимает
+	if _, err := Listen().DialContext(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("DialContext без Accept: %v", err)
+	}
+}
@@ -1,7 +1,144 @@
 package models
 
+import (
+	"go_micro_gRPS/internal/apperrors"
+	"mime"
+	"strings"
+	"time"
+)
+
+// MaxContentLength максимальный размер содержимого сообщения в байтах (ограничение брокера Kafka - 1 МБ)
+const MaxContentLength = 900 * 1024
+
+const (
+	// MaxKeyLength максимальная длина ключа сообщения в байтах
+	MaxKeyLength = 256
+	// MaxAttributes максимальное количество атрибутов сообщения
+	MaxAttributes = 64
+	// MaxAttributeLength максимальная длина имени или значения атрибута в байтах
+	MaxAttributeLength = 1024
+	// MaxDeliveryDelay максимальная задержка отложенной доставки
+	MaxDeliveryDelay = 365 * 24 * time.Hour
+	// MaxTTL максимальное время жизни сообщения
+	MaxTTL = 365 * 24 * time.Hour
+)
+
+// HeaderContentType заголовок записи Kafka с MIME-типом содержимого
+const HeaderContentType = "content-type"
+
+// HeaderExpiresAt заголовок записи Kafka с временем истечения срока жизни сообщения (RFC 3339).
+// Consumer пропускает записи, срок жизни которых истек.
+const HeaderExpiresAt = "expires-at"
+
+// reservedHeaders заголовки Kafka, которые устанавливает сервис (в нижнем регистре); атрибуты с такими
+// именами не принимаются независимо от регистра
+var reservedHeaders = map[string]bool{
+	HeaderContentType: true,
+	HeaderExpiresAt:   true,
+}
+
 type Message struct {
-	ID      int    `json:"id"`
-	Content string `json:"content"`
-	Status  string `json:"status"`
+	ID          int               `json:"id"`
+	Content     string            `json:"content"`
+	Status      string            `json:"status"`
+	CreatedAt   time.Time         `json:"created_at"`
+	CreatedBy   string            `json:"created_by"`             // Аутентифицированный отправитель
+	Key         string            `json:"key,omitempty"`          // Ключ записи Kafka
+	Attributes  map[string]string `json:"attributes,omitempty"`   // Атрибуты, передаваемые в заголовках Kafka
+	ContentType string            `json:"content_type,omitempty"` // MIME-тип содержимого
+	DeliverAt   time.Time         `json:"deliver_at"`             // Время отложенной отправки в Kafka (нулевое - немедленно)
+	ExpiresAt   time.Time         `json:"expires_at"`             // Время истечения срока жизни (нулевое - бессрочно)
+	Topic       string            `json:"topic"`                  // Топик Kafka, в который отправляется сообщение
+	Priority    string            `json:"priority"`               // Приоритет (полоса Kafka): high, normal или low
+}
+
+// Scheduled сообщает, должно ли сообщение быть отправлено планировщиком позже, а не немедленно.
+func (m Message) Scheduled(now time.Time) bool {
+	return m.DeliverAt.After(now)
+}
+
+// Headers возвращает заголовки записи Kafka для сообщения: атрибуты, content-type и expires-at.
+func (m Message) Headers() map[string]string {
+	if len(m.Attributes) == 0 && m.ContentType == "" && m.ExpiresAt.IsZero() {
+		return nil
+	}
+	headers := make(map[string]string, len(m.Attributes)+2)
+	for name, value := range m.Attributes {
+		headers[name] = value
+	}
+	if m.ContentType != "" {
+		headers[HeaderContentType] = m.ContentType
+	}
+	if !m.ExpiresAt.IsZero() {
+		headers[HeaderExpiresAt] = m.ExpiresAt.UTC().Format(time.RFC3339Nano)
+	}
+	return headers
+}
+
+// Expired сообщает, истек ли к моменту now срок жизни сообщения.
+func (m Message) Expired(now time.Time) bool {
+	return !m.ExpiresAt.IsZero() && !now.Before(m.ExpiresAt)
+}
+
+// ValidateContent проверяет содержимое сообщения перед сохранением
+func ValidateContent(content string) error {
+	if content == "" {
+		return apperrors.InvalidArgument("content", "content must not be empty")
+	}
+	if len(content) > MaxContentLength {
+		return apperrors.InvalidArgument("content", "content is too large")
+	}
+	return nil
+}
+
+// ValidatePriority проверяет приоритет сообщения; пустой приоритет означает normal.
+func ValidatePriority(priority string) error {
+	if priority == "" {
+		return nil
+	}
+	for _, p := range Priorities {
+		if priority == p {
+			return nil
+		}
+	}
+	return apperrors.InvalidArgument("priority", "priority must be one of high, normal, low")
+}
+
+// ValidateMessage проверяет содержимое, ключ, атрибуты, тип содержимого и приоритет сообщения перед сохранением
+func ValidateMessage(m Message) error {
+	if err := ValidateContent(m.Content); err != nil {
+		return err
+	}
+	if len(m.Key) > MaxKeyLength {
+		return apperrors.InvalidArgument("key", "key is too long")
+	}
+	if len(m.Attributes) > MaxAttributes {
+		return apperrors.InvalidArgument("attributes", "too many attributes")
+	}
+	for name, value := range m.Attributes {
+		if name == "" {
+			return apperrors.InvalidArgument("attributes", "attribute name must not be empty")
+		}
+		if len(name) > MaxAttributeLength || len(value) > MaxAttributeLength {
+			return apperrors.InvalidArgument("attributes", "attribute is too long")
+		}
+		if reservedHeaders[strings.ToLower(name)] {
+			return apperrors.InvalidArgument("attributes", "attribute name "+name+" is reserved")
+		}
+	}
+	if m.ContentType != "" {
+		if _, _, err := mime.ParseMediaType(m.ContentType); err != nil {
+			return apperrors.InvalidArgument("content_type", "invalid content type")
+		}
+	}
+	if err := ValidatePriority(m.Priority); err != nil {
+		return err
+	}
+	if m.DeliverAt.After(time.Now().Add(MaxDeliveryDelay)) {
+		return apperrors.InvalidArgument("deliver_at", "delivery time is too far in the future")
+	}
+	if !m.ExpiresAt.IsZero() && !m.DeliverAt.IsZero() && !m.ExpiresAt.After(m.DeliverAt) {
+		return apperrors.InvalidArgument("ttl", "message would expire before its delivery time")
+	}
+	return nil
 }
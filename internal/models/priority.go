@@ -0,0 +1,12 @@
+package models
+
+// Приоритеты сообщений. Каждому приоритету соответствует отдельная полоса - свой топик Kafka,
+// поэтому срочные сообщения не ждут в очереди за массовыми.
+const (
+	PriorityHigh   = "high"
+	PriorityNormal = "normal"
+	PriorityLow    = "low"
+)
+
+// Priorities приоритеты в порядке убывания; в этом порядке consumer выбирает полосы.
+var Priorities = []string{PriorityHigh, PriorityNormal, PriorityLow}
@@ -0,0 +1,70 @@
+package ratelimit
+
+import (
+	"context"
+	"go_micro_gRPS/internal/apperrors"
+	"log"
+
+	"google.golang.org/grpc"
+)
+
+// GRPCLimiter применяет Limiter к выбранным gRPC-методам. Для unary-методов токен
+// расходуется на вызов, для потоковых - на каждое полученное от клиента сообщение.
+type GRPCLimiter struct {
+	limiter *Limiter
+	methods map[string]bool
+}
+
+// NewGRPCLimiter создаёт перехватчики для методов с полными именами methods.
+func NewGRPCLimiter(limiter *Limiter, methods ...string) *GRPCLimiter {
+	g := &GRPCLimiter{limiter: limiter, methods: make(map[string]bool, len(methods))}
+	for _, m := range methods {
+		g.methods[m] = true
+	}
+	return g
+}
+
+// UnaryServerInterceptor ограничивает частоту unary-вызовов.
+func (g *GRPCLimiter) UnaryServerInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	if g.methods[info.FullMethod] {
+		if err := g.allow(ClientID(ctx), info.FullMethod); err != nil {
+			return nil, apperrors.ToGRPC(err)
+		}
+	}
+	return handler(ctx, req)
+}
+
+// StreamServerInterceptor ограничивает частоту сообщений в потоковых вызовах.
+func (g *GRPCLimiter) StreamServerInterceptor(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	if !g.methods[info.FullMethod] {
+		return handler(srv, ss)
+	}
+	return handler(srv, &limitedStream{ServerStream: ss, limiter: g, client: ClientID(ss.Context()), method: info.FullMethod})
+}
+
+func (g *GRPCLimiter) allow(client, method string) error {
+	ok, retryAfter := g.limiter.Allow(client)
+	if ok {
+		return nil
+	}
+	log.Printf("Превышен лимит запросов: client=%s method=%s", client, method)
+	return apperrors.ResourceExhausted("rate limit exceeded", retryAfter)
+}
+
+// limitedStream расходует токен на каждое сообщение, полученное от клиента.
+type limitedStream struct {
+	grpc.ServerStream
+	limiter *GRPCLimiter
+	client  string
+	method  string
+}
+
+func (s *limitedStream) RecvMsg(m interface{}) error {
+	if err := s.ServerStream.RecvMsg(m); err != nil {
+		return err
+	}
+	if err := s.limiter.allow(s.client, s.method); err != nil {
+		return apperrors.ToGRPC(err)
+	}
+	return nil
+}
@@ -0,0 +1,18 @@
+package ratelimit
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+)
+
+// UsageHandler возвращает текущее использование лимитов по клиентам: лимит, доступные токены
+// и счетчики пропущенных и отклоненных запросов.
+func (l *Limiter) UsageHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(l.Usage()); err != nil {
+			log.Printf("Error encoding JSON response: %v", err)
+		}
+	}
+}
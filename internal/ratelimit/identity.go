@@ -0,0 +1,58 @@
+package ratelimit
+
+import (
+	"context"
+	"go_micro_gRPS/internal/auth"
+	"go_micro_gRPS/internal/memconn"
+	"net"
+	"strings"
+
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+)
+
+// ClientID определяет клиента gRPC-вызова: аутентифицированный subject,
+// затем CN проверенного клиентского сертификата mTLS, затем IP-адрес. Сертификат,
+// не прошедший проверку (режим request), не учитывается: его CN выбирает сам клиент.
+func ClientID(ctx context.Context) string {
+	if p, ok := auth.FromContext(ctx); ok {
+		return "principal:" + p.Subject
+	}
+
+	pr, ok := peer.FromContext(ctx)
+	if !ok {
+		return "unknown"
+	}
+	if tlsInfo, ok := pr.AuthInfo.(credentials.TLSInfo); ok && len(tlsInfo.State.VerifiedChains) > 0 {
+		return "mtls:" + tlsInfo.State.VerifiedChains[0][0].Subject.CommonName
+	}
+	return "ip:" + hostOnly(PeerAddress(ctx))
+}
+
+// PeerAddress возвращает адрес клиента. Для вызовов через HTTP-шлюз это адрес HTTP-клиента
+// из метаданных x-forwarded-for, а не внутреннее соединение шлюза.
+func PeerAddress(ctx context.Context) string {
+	pr, ok := peer.FromContext(ctx)
+	if !ok {
+		return "unknown"
+	}
+	if pr.Addr.Network() == memconn.Network {
+		if md, ok := metadata.FromIncomingContext(ctx); ok {
+			if values := md.Get("x-forwarded-for"); len(values) > 0 {
+				// Шлюз дописывает адрес клиента в конец списка
+				hops := strings.Split(values[len(values)-1], ",")
+				return strings.TrimSpace(hops[len(hops)-1])
+			}
+		}
+	}
+	return pr.Addr.String()
+}
+
+func hostOnly(addr string) string {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return addr
+	}
+	return host
+}
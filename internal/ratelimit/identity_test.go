@@ -0,0 +1,69 @@
+package ratelimit
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"go_micro_gRPS/internal/auth"
+	"go_micro_gRPS/internal/memconn"
+	"net"
+	"testing"
+
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+)
+
+// gatewayAddr адрес внутреннего соединения HTTP-шлюза
+type gatewayAddr struct{}
+
+func (gatewayAddr) Network() string { return memconn.Network }
+func (gatewayAddr) String() string  { return memconn.Network }
+
+func TestClientID(t *testing.T) {
+	tcpAddr := &net.TCPAddr{IP: net.ParseIP("10.0.0.5"), Port: 41000}
+	cert := &x509.Certificate{Subject: pkix.Name{CommonName: "batch-job"}}
+	// Сертификат без проверки (GRPC_TLS_CLIENT_AUTH=request): CN выбирает клиент
+	unverified := credentials.TLSInfo{State: tls.ConnectionState{PeerCertificates: []*x509.Certificate{cert}}}
+	verified := credentials.TLSInfo{State: tls.ConnectionState{
+		PeerCertificates: []*x509.Certificate{cert},
+		VerifiedChains:   [][]*x509.Certificate{{cert}},
+	}}
+
+	tests := []struct {
+		name      string
+		principal *auth.Principal
+		peer      *peer.Peer
+		md        metadata.MD
+		want      string
+	}{
+		{"без адреса", nil, nil, nil, "unknown"},
+		{"IP-адрес без порта", nil, &peer.Peer{Addr: tcpAddr}, nil, "ip:10.0.0.5"},
+		{"аутентифицированный клиент", &auth.Principal{Subject: "svc"}, &peer.Peer{Addr: tcpAddr, AuthInfo: verified}, nil, "principal:svc"},
+		{"проверенный сертификат", nil, &peer.Peer{Addr: tcpAddr, AuthInfo: verified}, nil, "mtls:batch-job"},
+		{"непроверенный сертификат", nil, &peer.Peer{Addr: tcpAddr, AuthInfo: unverified}, nil, "ip:10.0.0.5"},
+		{"x-forwarded-for прямого вызова не учитывается", nil, &peer.Peer{Addr: tcpAddr},
+			metadata.Pairs("x-forwarded-for", "192.0.2.1"), "ip:10.0.0.5"},
+		{"вызов через шлюз", nil, &peer.Peer{Addr: gatewayAddr{}},
+			metadata.Pairs("x-forwarded-for", "192.0.2.1, 198.51.100.7"), "ip:198.51.100.7"},
+		{"вызов через шлюз без x-forwarded-for", nil, &peer.Peer{Addr: gatewayAddr{}}, nil, "ip:" + memconn.Network},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			if tt.principal != nil {
+				ctx = auth.NewContext(ctx, tt.principal)
+			}
+			if tt.peer != nil {
+				ctx = peer.NewContext(ctx, tt.peer)
+			}
+			if tt.md != nil {
+				ctx = metadata.NewIncomingContext(ctx, tt.md)
+			}
+			if got := ClientID(ctx); got != tt.want {
+				t.Errorf("ClientID = %q, ожидалось %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,159 @@
+// Package ratelimit ограничение частоты запросов по алгоритму token bucket
+// с отдельным ведром для каждого клиента (API-ключ/subject, сертификат mTLS или IP).
+package ratelimit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"sync"
+	"time"
+)
+
+// idleTimeout время простоя, после которого полное ведро клиента удаляется из памяти
+const idleTimeout = 10 * time.Minute
+
+// Limit параметры ведра: скорость пополнения (токенов в секунду) и ёмкость.
+type Limit struct {
+	RPS   float64 `json:"rps"`
+	Burst int     `json:"burst"`
+}
+
+// Usage текущее использование лимита клиентом.
+type Usage struct {
+	Client   string  `json:"client"`
+	Limit    Limit   `json:"limit"`
+	Tokens   float64 `json:"tokens"`   // Доступные токены на текущий момент
+	Allowed  uint64  `json:"allowed"`  // Пропущенные запросы
+	Rejected uint64  `json:"rejected"` // Отклоненные запросы
+}
+
+type bucket struct {
+	limit    Limit
+	tokens   float64
+	last     time.Time // Время последнего пополнения
+	seen     time.Time // Время последнего запроса клиента
+	allowed  uint64
+	rejected uint64
+}
+
+// Limiter набор ведер token bucket по идентификаторам клиентов.
+type Limiter struct {
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	def       Limit
+	perClient map[string]Limit
+	now       func() time.Time
+}
+
+// NewLimiter создаёт Limiter с лимитом по умолчанию и индивидуальными лимитами для клиентов.
+// Ключи perClient совпадают с идентификаторами клиентов, например "principal:batch-job" или "ip:10.0.0.5".
+func NewLimiter(def Limit, perClient map[string]Limit) *Limiter {
+	return &Limiter{
+		buckets:   make(map[string]*bucket),
+		def:       def,
+		perClient: perClient,
+		now:       time.Now,
+	}
+}
+
+// LoadClientLimits читает индивидуальные лимиты из JSON-файла:
+// {"principal:batch-job": {"rps": 10, "burst": 20}}
+func LoadClientLimits(path string) (map[string]Limit, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка чтения лимитов клиентов: %v", err)
+	}
+	var limits map[string]Limit
+	if err := json.Unmarshal(data, &limits); err != nil {
+		return nil, fmt.Errorf("ошибка разбора лимитов клиентов: %v", err)
+	}
+	return limits, nil
+}
+
+// Allow расходует токен клиента. Если токенов нет, возвращает false и время до появления следующего токена.
+func (l *Limiter) Allow(client string) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	b, ok := l.buckets[client]
+	if !ok {
+		limit, ok := l.perClient[client]
+		if !ok {
+			limit = l.def
+		}
+		b = &bucket{limit: limit, tokens: float64(limit.Burst), last: now}
+		l.buckets[client] = b
+	}
+	b.refill(now)
+	b.seen = now
+
+	if b.tokens >= 1 {
+		b.tokens--
+		b.allowed++
+		return true, 0
+	}
+
+	b.rejected++
+	if b.limit.RPS <= 0 {
+		return false, time.Minute
+	}
+	wait := time.Duration(math.Ceil((1 - b.tokens) / b.limit.RPS * float64(time.Second)))
+	return false, wait
+}
+
+// Usage возвращает использование лимитов по всем активным клиентам.
+func (l *Limiter) Usage() []Usage {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	usage := make([]Usage, 0, len(l.buckets))
+	for client, b := range l.buckets {
+		b.refill(now)
+		usage = append(usage, Usage{
+			Client:   client,
+			Limit:    b.limit,
+			Tokens:   b.tokens,
+			Allowed:  b.allowed,
+			Rejected: b.rejected,
+		})
+	}
+	sort.Slice(usage, func(i, j int) bool { return usage[i].Client < usage[j].Client })
+	return usage
+}
+
+// Cleanup периодически удаляет ведра клиентов, простаивающих дольше idleTimeout, до отмены контекста.
+func (l *Limiter) Cleanup(ctx context.Context) {
+	ticker := time.NewTicker(idleTimeout)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			l.mu.Lock()
+			now := l.now()
+			for client, b := range l.buckets {
+				if now.Sub(b.seen) > idleTimeout {
+					delete(l.buckets, client)
+				}
+			}
+			l.mu.Unlock()
+		}
+	}
+}
+
+// refill пополняет ведро токенами, накопленными с последнего обращения.
+func (b *bucket) refill(now time.Time) {
+	elapsed := now.Sub(b.last).Seconds()
+	if elapsed > 0 {
+		b.tokens = math.Min(float64(b.limit.Burst), b.tokens+elapsed*b.limit.RPS)
+		b.last = now
+	}
+}
@@ -0,0 +1,122 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+)
+
+// fakeClock управляемое время для Limiter.now
+type fakeClock struct {
+	t time.Time
+}
+
+func (c *fakeClock) now() time.Time          { return c.t }
+func (c *fakeClock) advance(d time.Duration) { c.t = c.t.Add(d) }
+
+func newTestLimiter(def Limit, perClient map[string]Limit) (*Limiter, *fakeClock) {
+	clock := &fakeClock{t: time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)}
+	l := NewLimiter(def, perClient)
+	l.now = clock.now
+	return l, clock
+}
+
+func TestAllow(t *testing.T) {
+	// step шаг сценария: пауза перед запросом и ожидаемый результат
+	type step struct {
+		advance    time.Duration
+		allowed    bool
+		retryAfter time.Duration
+	}
+	tests := []struct {
+		name  string
+		limit Limit
+		steps []step
+	}{
+		{
+			name:  "ведро полное в начале и пустеет после burst запросов",
+			limit: Limit{RPS: 1, Burst: 3},
+			steps: []step{{0, true, 0}, {0, true, 0}, {0, true, 0}, {0, false, time.Second}},
+		},
+		{
+			name:  "Retry-After - время до следующего целого токена",
+			limit: Limit{RPS: 4, Burst: 1},
+			steps: []step{{0, true, 0}, {100 * time.Millisecond, false, 150 * time.Millisecond}},
+		},
+		{
+			name:  "токены пополняются со скоростью RPS",
+			limit: Limit{RPS: 2, Burst: 1},
+			steps: []step{{0, true, 0}, {0, false, 500 * time.Millisecond}, {500 * time.Millisecond, true, 0}},
+		},
+		{
+			name:  "пополнение не превышает burst",
+			limit: Limit{RPS: 10, Burst: 2},
+			steps: []step{{0, true, 0}, {0, true, 0}, {time.Hour, true, 0}, {0, true, 0}, {0, false, 100 * time.Millisecond}},
+		},
+		{
+			name:  "без пополнения повтор через минуту",
+			limit: Limit{RPS: 0, Burst: 1},
+			steps: []step{{0, true, 0}, {time.Hour, false, time.Minute}},
+		},
+		{
+			name:  "нулевой burst отклоняет все запросы",
+			limit: Limit{RPS: 1, Burst: 0},
+			steps: []step{{0, false, time.Second}, {time.Hour, false, time.Second}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l, clock := newTestLimiter(tt.limit, nil)
+			for i, s := range tt.steps {
+				clock.advance(s.advance)
+				allowed, retryAfter := l.Allow("ip:10.0.0.1")
+				if allowed != s.allowed || retryAfter != s.retryAfter {
+					t.Fatalf("запрос %d: allowed=%v retryAfter=%v, ожидалось %v %v", i, allowed, retryAfter, s.allowed, s.retryAfter)
+				}
+			}
+		})
+	}
+}
+
+func TestPerClientLimits(t *testing.T) {
+	l, _ := newTestLimiter(Limit{RPS: 1, Burst: 1}, map[string]Limit{"principal:batch-job": {RPS: 1, Burst: 3}})
+
+	tests := []struct {
+		client  string
+		allowed int // Запросов, пропущенных подряд из полного ведра
+	}{
+		{"principal:batch-job", 3},
+		{"principal:other", 1},
+		{"ip:10.0.0.1", 1},
+	}
+	for _, tt := range tests {
+		allowed := 0
+		for i := 0; i < 5; i++ {
+			if ok, _ := l.Allow(tt.client); ok {
+				allowed++
+			}
+		}
+		if allowed != tt.allowed {
+			t.Errorf("%s: пропущено %d запросов, ожидалось %d", tt.client, allowed, tt.allowed)
+		}
+	}
+}
+
+func TestUsage(t *testing.T) {
+	l, clock := newTestLimiter(Limit{RPS: 1, Burst: 2}, nil)
+	for i := 0; i < 3; i++ {
+		l.Allow("ip:10.0.0.2")
+	}
+	l.Allow("ip:10.0.0.1")
+	clock.advance(500 * time.Millisecond)
+
+	usage := l.Usage()
+	if len(usage) != 2 || usage[0].Client != "ip:10.0.0.1" || usage[1].Client != "ip:10.0.0.2" {
+		t.Fatalf("использование %+v, ожидались два клиента по возрастанию", usage)
+	}
+	if u := usage[1]; u.Allowed != 2 || u.Rejected != 1 || u.Tokens != 0.5 {
+		t.Errorf("ip:10.0.0.2: %+v, ожидалось allowed=2 rejected=1 tokens=0.5", u)
+	}
+	if u := usage[0]; u.Allowed != 1 || u.Rejected != 0 || u.Tokens != 1.5 {
+		t.Errorf("ip:10.0.0.1: %+v, ожидалось allowed=1 rejected=0 tokens=1.5", u)
+	}
+}
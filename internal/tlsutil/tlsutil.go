@@ -0,0 +1,196 @@
+// Package tlsutil настройка TLS для gRPC-сервера и клиента с перезагрузкой сертификатов при изменении файлов.
+package tlsutil
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Reloader хранит текущий сертификат сервера и пул CA для проверки клиентов
+// и перечитывает их, когда файлы на диске изменяются.
+type Reloader struct {
+	certFile, keyFile, caFile string
+
+	mu       sync.RWMutex
+	cert     *tls.Certificate
+	clientCA *x509.CertPool
+	modTimes map[string]time.Time
+}
+
+// NewReloader загружает сертификат и ключ сервера и, если указан caFile, пул CA клиентов.
+func NewReloader(certFile, keyFile, caFile string) (*Reloader, error) {
+	r := &Reloader{certFile: certFile, keyFile: keyFile, caFile: caFile}
+	if err := r.reload(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Watch проверяет время изменения файлов с периодом interval и перезагружает их до отмены контекста.
+// При ошибке загрузки продолжает использоваться прежний сертификат. interval <= 0 отключает перезагрузку.
+func (r *Reloader) Watch(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if !r.changed() {
+				continue
+			}
+			if err := r.reload(); err != nil {
+				log.Printf("Ошибка перезагрузки TLS-сертификатов: %v", err)
+				continue
+			}
+			log.Println("TLS-сертификаты перезагружены")
+		}
+	}
+}
+
+// ServerConfig возвращает конфигурацию TLS сервера. Сертификат и пул CA берутся
+// при каждом рукопожатии, поэтому перезагрузка применяется к новым соединениям без перезапуска.
+func (r *Reloader) ServerConfig(clientAuth tls.ClientAuthType) *tls.Config {
+	return &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		GetConfigForClient: func(*tls.ClientHelloInfo) (*tls.Config, error) {
+			r.mu.RLock()
+			defer r.mu.RUnlock()
+			return &tls.Config{
+				MinVersion:   tls.VersionTLS12,
+				Certificates: []tls.Certificate{*r.cert},
+				ClientAuth:   clientAuth,
+				ClientCAs:    r.clientCA,
+				NextProtos:   []string{"h2"},
+			}, nil
+		},
+	}
+}
+
+// reload загружает все файлы и атомарно заменяет текущие значения.
+func (r *Reloader) reload() error {
+	modTimes, err := r.readModTimes()
+	if err != nil {
+		return err
+	}
+
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return fmt.Errorf("ошибка загрузки сертификата сервера: %v", err)
+	}
+
+	var clientCA *x509.CertPool
+	if r.caFile != "" {
+		clientCA, err = LoadCertPool(r.caFile)
+		if err != nil {
+			return err
+		}
+	}
+
+	r.mu.Lock()
+	r.cert = &cert
+	r.clientCA = clientCA
+	r.modTimes = modTimes
+	r.mu.Unlock()
+	return nil
+}
+
+// changed сообщает, изменился ли хотя бы один из файлов с момента последней загрузки.
+func (r *Reloader) changed() bool {
+	modTimes, err := r.readModTimes()
+	if err != nil {
+		log.Printf("Ошибка проверки TLS-сертификатов: %v", err)
+		return false
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for file, modTime := range modTimes {
+		if !modTime.Equal(r.modTimes[file]) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *Reloader) readModTimes() (map[string]time.Time, error) {
+	modTimes := make(map[string]time.Time)
+	for _, file := range []string{r.certFile, r.keyFile, r.caFile} {
+		if file == "" {
+			continue
+		}
+		info, err := os.Stat(file)
+		if err != nil {
+			return nil, fmt.Errorf("ошибка чтения %s: %v", file, err)
+		}
+		modTimes[file] = info.ModTime()
+	}
+	return modTimes, nil
+}
+
+// LoadCertPool загружает пул сертификатов CA из PEM-файла.
+func LoadCertPool(caFile string) (*x509.CertPool, error) {
+	pem, err := os.ReadFile(caFile)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка чтения CA %s: %v", caFile, err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("в файле %s нет корректных сертификатов", caFile)
+	}
+	return pool, nil
+}
+
+// ParseClientAuth преобразует режим проверки клиентских сертификатов из конфигурации:
+// "none" (или пусто), "request", "verify-if-given", "require".
+func ParseClientAuth(mode string) (tls.ClientAuthType, error) {
+	switch strings.ToLower(mode) {
+	case "", "none":
+		return tls.NoClientCert, nil
+	case "request":
+		return tls.RequestClientCert, nil
+	case "verify-if-given":
+		return tls.VerifyClientCertIfGiven, nil
+	case "require":
+		return tls.RequireAndVerifyClientCert, nil
+	default:
+		return tls.NoClientCert, fmt.Errorf("неизвестный режим проверки клиентов: %q", mode)
+	}
+}
+
+// ClientConfig возвращает конфигурацию TLS клиента. caFile задаёт CA для проверки сервера
+// (пусто - системный пул), certFile и keyFile - клиентский сертификат для mTLS (необязательно).
+func ClientConfig(caFile, certFile, keyFile, serverName string) (*tls.Config, error) {
+	cfg := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		ServerName: serverName,
+	}
+
+	if caFile != "" {
+		pool, err := LoadCertPool(caFile)
+		if err != nil {
+			return nil, err
+		}
+		cfg.RootCAs = pool
+	}
+
+	if certFile != "" || keyFile != "" {
+		cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err != nil {
+			return nil, fmt.Errorf("ошибка загрузки клиентского сертификата: %v", err)
+		}
+		cfg.Certificates = []tls.Certificate{cert}
+	}
+	return cfg, nil
+}
@@ -20,7 +20,14 @@ const _ = grpc.SupportPackageIsVersion9
 
 const (
 	MessageService_SendMessage_FullMethodName          = "/service.MessageService/SendMessage"
+	MessageService_SendMessageStream_FullMethodName    = "/service.MessageService/SendMessageStream"
+	MessageService_BatchSendMessages_FullMethodName    = "/service.MessageService/BatchSendMessages"
 	MessageService_GetProcessedMessages_FullMethodName = "/service.MessageService/GetProcessedMessages"
+	MessageService_GetStats_FullMethodName             = "/service.MessageService/GetStats"
+	MessageService_GetMessage_FullMethodName           = "/service.MessageService/GetMessage"
+	MessageService_ListMessages_FullMethodName         = "/service.MessageService/ListMessages"
+	MessageService_RetryMessage_FullMethodName         = "/service.MessageService/RetryMessage"
+	MessageService_SubscribeMessages_FullMethodName    = "/service.MessageService/SubscribeMessages"
 )
 
 // MessageServiceClient is the client API for MessageService service.
@@ -29,8 +36,28 @@ const (
 //
 // Определение gRPC сервиса для сообщений
 type MessageServiceClient interface {
+	// Отправка сообщения: сохранение в БД и публикация в Kafka.
+	// Запрос с ключом идемпотентности (метаданные idempotency-key) выполняется не более одного раза,
+	// повтор с тем же ключом возвращает исходное сообщение и его текущий статус, в том числе после
+	// неудачной отправки (повторная отправка - RetryMessage).
 	SendMessage(ctx context.Context, in *MessageRequest, opts ...grpc.CallOption) (*MessageResponse, error)
+	// Потоковая отправка сообщений с подтверждением (ack) каждого из них
+	SendMessageStream(ctx context.Context, opts ...grpc.CallOption) (grpc.BidiStreamingClient[StreamMessageRequest, MessageAck], error)
+	// Пакетная отправка: все сообщения сохраняются в одной транзакции и отправляются в Kafka одним вызовом
+	BatchSendMessages(ctx context.Context, opts ...grpc.CallOption) (grpc.ClientStreamingClient[MessageRequest, BatchSendResponse], error)
+	// Количество обработанных сообщений
 	GetProcessedMessages(ctx context.Context, in *EmptyRequest, opts ...grpc.CallOption) (*MessageStats, error)
+	// Статистика: количество сообщений по статусам, скорость приема и обработки, задержка обработки.
+	// Может быть ограничена одним топиком.
+	GetStats(ctx context.Context, in *StatsRequest, opts ...grpc.CallOption) (*Stats, error)
+	// Получение сообщения по ID
+	GetMessage(ctx context.Context, in *GetMessageRequest, opts ...grpc.CallOption) (*Message, error)
+	// Список сообщений с фильтрацией по статусу, топику, приоритету и времени создания и постраничной выдачей
+	ListMessages(ctx context.Context, in *ListMessagesRequest, opts ...grpc.CallOption) (*ListMessagesResponse, error)
+	// Повторная отправка в Kafka сообщения со статусом failed, срок жизни которого не истек
+	RetryMessage(ctx context.Context, in *RetryMessageRequest, opts ...grpc.CallOption) (*Message, error)
+	// Поток сообщений, прочитанных Kafka consumer'ом, в реальном времени
+	SubscribeMessages(ctx context.Context, in *SubscribeRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[ConsumedMessage], error)
 }
 
 type messageServiceClient struct {
@@ -51,6 +78,32 @@ func (c *messageServiceClient) SendMessage(ctx context.Context, in *MessageReque
 	return out, nil
 }
 
+func (c *messageServiceClient) SendMessageStream(ctx context.Context, opts ...grpc.CallOption) (grpc.BidiStreamingClient[StreamMessageRequest, MessageAck], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &MessageService_ServiceDesc.Streams[0], MessageService_SendMessageStream_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[StreamMessageRequest, MessageAck]{ClientStream: stream}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type MessageService_SendMessageStreamClient = grpc.BidiStreamingClient[StreamMessageRequest, MessageAck]
+
+func (c *messageServiceClient) BatchSendMessages(ctx context.Context, opts ...grpc.CallOption) (grpc.ClientStreamingClient[MessageRequest, BatchSendResponse], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &MessageService_ServiceDesc.Streams[1], MessageService_BatchSendMessages_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[MessageRequest, BatchSendResponse]{ClientStream: stream}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type MessageService_BatchSendMessagesClient = grpc.ClientStreamingClient[MessageRequest, BatchSendResponse]
+
 func (c *messageServiceClient) GetProcessedMessages(ctx context.Context, in *EmptyRequest, opts ...grpc.CallOption) (*MessageStats, error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
 	out := new(MessageStats)
@@ -61,14 +114,93 @@ func (c *messageServiceClient) GetProcessedMessages(ctx context.Context, in *Emp
 	return out, nil
 }
 
+func (c *messageServiceClient) GetStats(ctx context.Context, in *StatsRequest, opts ...grpc.CallOption) (*Stats, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Stats)
+	err := c.cc.Invoke(ctx, MessageService_GetStats_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *messageServiceClient) GetMessage(ctx context.Context, in *GetMessageRequest, opts ...grpc.CallOption) (*Message, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Message)
+	err := c.cc.Invoke(ctx, MessageService_GetMessage_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *messageServiceClient) ListMessages(ctx context.Context, in *ListMessagesRequest, opts ...grpc.CallOption) (*ListMessagesResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListMessagesResponse)
+	err := c.cc.Invoke(ctx, MessageService_ListMessages_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *messageServiceClient) RetryMessage(ctx context.Context, in *RetryMessageRequest, opts ...grpc.CallOption) (*Message, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Message)
+	err := c.cc.Invoke(ctx, MessageService_RetryMessage_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *messageServiceClient) SubscribeMessages(ctx context.Context, in *SubscribeRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[ConsumedMessage], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &MessageService_ServiceDesc.Streams[2], MessageService_SubscribeMessages_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[SubscribeRequest, ConsumedMessage]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type MessageService_SubscribeMessagesClient = grpc.ServerStreamingClient[ConsumedMessage]
+
 // MessageServiceServer is the server API for MessageService service.
 // All implementations must embed UnimplementedMessageServiceServer
 // for forward compatibility.
 //
 // Определение gRPC сервиса для сообщений
 type MessageServiceServer interface {
+	// Отправка сообщения: сохранение в БД и публикация в Kafka.
+	// Запрос с ключом идемпотентности (метаданные idempotency-key) выполняется не более одного раза,
+	// повтор с тем же ключом возвращает исходное сообщение и его текущий статус, в том числе после
+	// неудачной отправки (повторная отправка - RetryMessage).
 	SendMessage(context.Context, *MessageRequest) (*MessageResponse, error)
+	// Потоковая отправка сообщений с подтверждением (ack) каждого из них
+	SendMessageStream(grpc.BidiStreamingServer[StreamMessageRequest, MessageAck]) error
+	// Пакетная отправка: все сообщения сохраняются в одной транзакции и отправляются в Kafka одним вызовом
+	BatchSendMessages(grpc.ClientStreamingServer[MessageRequest, BatchSendResponse]) error
+	// Количество обработанных сообщений
 	GetProcessedMessages(context.Context, *EmptyRequest) (*MessageStats, error)
+	// Статистика: количество сообщений по статусам, скорость приема и обработки, задержка обработки.
+	// Может быть ограничена одним топиком.
+	GetStats(context.Context, *StatsRequest) (*Stats, error)
+	// Получение сообщения по ID
+	GetMessage(context.Context, *GetMessageRequest) (*Message, error)
+	// Список сообщений с фильтрацией по статусу, топику, приоритету и времени создания и постраничной выдачей
+	ListMessages(context.Context, *ListMessagesRequest) (*ListMessagesResponse, error)
+	// Повторная отправка в Kafka сообщения со статусом failed, срок жизни которого не истек
+	RetryMessage(context.Context, *RetryMessageRequest) (*Message, error)
+	// Поток сообщений, прочитанных Kafka consumer'ом, в реальном времени
+	SubscribeMessages(*SubscribeRequest, grpc.ServerStreamingServer[ConsumedMessage]) error
 	mustEmbedUnimplementedMessageServiceServer()
 }
 
@@ -82,9 +214,30 @@ type UnimplementedMessageServiceServer struct{}
 func (UnimplementedMessageServiceServer) SendMessage(context.Context, *MessageRequest) (*MessageResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method SendMessage not implemented")
 }
+func (UnimplementedMessageServiceServer) SendMessageStream(grpc.BidiStreamingServer[StreamMessageRequest, MessageAck]) error {
+	return status.Errorf(codes.Unimplemented, "method SendMessageStream not implemented")
+}
+func (UnimplementedMessageServiceServer) BatchSendMessages(grpc.ClientStreamingServer[MessageRequest, BatchSendResponse]) error {
+	return status.Errorf(codes.Unimplemented, "method BatchSendMessages not implemented")
+}
 func (UnimplementedMessageServiceServer) GetProcessedMessages(context.Context, *EmptyRequest) (*MessageStats, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method GetProcessedMessages not implemented")
 }
+func (UnimplementedMessageServiceServer) GetStats(context.Context, *StatsRequest) (*Stats, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetStats not implemented")
+}
+func (UnimplementedMessageServiceServer) GetMessage(context.Context, *GetMessageRequest) (*Message, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetMessage not implemented")
+}
+func (UnimplementedMessageServiceServer) ListMessages(context.Context, *ListMessagesRequest) (*ListMessagesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListMessages not implemented")
+}
+func (UnimplementedMessageServiceServer) RetryMessage(context.Context, *RetryMessageRequest) (*Message, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RetryMessage not implemented")
+}
+func (UnimplementedMessageServiceServer) SubscribeMessages(*SubscribeRequest, grpc.ServerStreamingServer[ConsumedMessage]) error {
+	return status.Errorf(codes.Unimplemented, "method SubscribeMessages not implemented")
+}
 func (UnimplementedMessageServiceServer) mustEmbedUnimplementedMessageServiceServer() {}
 func (UnimplementedMessageServiceServer) testEmbeddedByValue()                        {}
 
@@ -124,6 +277,20 @@ func _MessageService_SendMessage_Handler(srv interface{}, ctx context.Context, d
 	return interceptor(ctx, in, info, handler)
 }
 
+func _MessageService_SendMessageStream_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(MessageServiceServer).SendMessageStream(&grpc.GenericServerStream[StreamMessageRequest, MessageAck]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type MessageService_SendMessageStreamServer = grpc.BidiStreamingServer[StreamMessageRequest, MessageAck]
+
+func _MessageService_BatchSendMessages_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(MessageServiceServer).BatchSendMessages(&grpc.GenericServerStream[MessageRequest, BatchSendResponse]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type MessageService_BatchSendMessagesServer = grpc.ClientStreamingServer[MessageRequest, BatchSendResponse]
+
 func _MessageService_GetProcessedMessages_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(EmptyRequest)
 	if err := dec(in); err != nil {
@@ -142,6 +309,89 @@ func _MessageService_GetProcessedMessages_Handler(srv interface{}, ctx context.C
 	return interceptor(ctx, in, info, handler)
 }
 
+func _MessageService_GetStats_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(StatsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MessageServiceServer).GetStats(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MessageService_GetStats_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MessageServiceServer).GetStats(ctx, req.(*StatsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MessageService_GetMessage_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetMessageRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MessageServiceServer).GetMessage(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MessageService_GetMessage_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MessageServiceServer).GetMessage(ctx, req.(*GetMessageRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MessageService_ListMessages_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListMessagesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MessageServiceServer).ListMessages(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MessageService_ListMessages_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MessageServiceServer).ListMessages(ctx, req.(*ListMessagesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MessageService_RetryMessage_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RetryMessageRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MessageServiceServer).RetryMessage(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MessageService_RetryMessage_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MessageServiceServer).RetryMessage(ctx, req.(*RetryMessageRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MessageService_SubscribeMessages_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(SubscribeRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(MessageServiceServer).SubscribeMessages(m, &grpc.GenericServerStream[SubscribeRequest, ConsumedMessage]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type MessageService_SubscribeMessagesServer = grpc.ServerStreamingServer[ConsumedMessage]
+
 // MessageService_ServiceDesc is the grpc.ServiceDesc for MessageService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -157,7 +407,40 @@ var MessageService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "GetProcessedMessages",
 			Handler:    _MessageService_GetProcessedMessages_Handler,
 		},
+		{
+			MethodName: "GetStats",
+			Handler:    _MessageService_GetStats_Handler,
+		},
+		{
+			MethodName: "GetMessage",
+			Handler:    _MessageService_GetMessage_Handler,
+		},
+		{
+			MethodName: "ListMessages",
+			Handler:    _MessageService_ListMessages_Handler,
+		},
+		{
+			MethodName: "RetryMessage",
+			Handler:    _MessageService_RetryMessage_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "SendMessageStream",
+			Handler:       _MessageService_SendMessageStream_Handler,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
+		{
+			StreamName:    "BatchSendMessages",
+			Handler:       _MessageService_BatchSendMessages_Handler,
+			ClientStreams: true,
+		},
+		{
+			StreamName:    "SubscribeMessages",
+			Handler:       _MessageService_SubscribeMessages_Handler,
+			ServerStreams: true,
+		},
 	},
-	Streams:  []grpc.StreamDesc{},
 	Metadata: "service.proto",
 }
@@ -0,0 +1,99 @@
+package server
+
+import (
+	"fmt"
+	"go_micro_gRPS/internal/apperrors"
+	"go_micro_gRPS/internal/auth"
+	"go_micro_gRPS/internal/database"
+	"go_micro_gRPS/internal/kafka_services"
+	"go_micro_gRPS/internal/models"
+	pb "go_micro_gRPS/proto/go_micro_gRPC/proto"
+	"io"
+	"log"
+)
+
+// maxBatchSize максимальное количество сообщений в одном вызове BatchSendMessages
+const maxBatchSize = 10000
+
+// BatchSendMessages Метод BatchSendMessages принимает пакет сообщений, сохраняет их в БД одной транзакцией
+// и отправляет в Kafka одним вызовом, возвращая ID сообщений и ошибки по отдельным сообщениям.
+func (s *Server) BatchSendMessages(stream pb.MessageService_BatchSendMessagesServer) error {
+	ctx := stream.Context()
+
+	var requests []*pb.MessageRequest
+	for {
+		req, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			log.Printf("Error receiving batch message: %v", err)
+			return apperrors.ToGRPC(err)
+		}
+		if len(requests) == maxBatchSize {
+			return apperrors.ToGRPC(apperrors.InvalidArgument("", fmt.Sprintf("batch exceeds %d messages", maxBatchSize)))
+		}
+		requests = append(requests, req)
+	}
+
+	res := &pb.BatchSendResponse{Ids: make([]int32, len(requests))}
+
+	// Отклоняем некорректные сообщения, остальные сохраняем одной транзакцией
+	var indexes []int
+	var messages []models.Message
+	createdBy := auth.SubjectFromContext(ctx)
+	for i, req := range requests {
+		msg, err := s.messageFromRequest(req, createdBy)
+		if err != nil {
+			res.Failures = append(res.Failures, &pb.BatchItemFailure{Index: int32(i), Error: err.Error()})
+			continue
+		}
+		indexes = append(indexes, i)
+		messages = append(messages, msg)
+	}
+	if len(messages) == 0 {
+		return stream.SendAndClose(res)
+	}
+
+	ids, err := database.SaveMessages(ctx, s.db, messages)
+	if err != nil {
+		log.Printf("Error saving batch to database: %v", err)
+		return apperrors.ToGRPC(err)
+	}
+
+	// Отложенные сообщения отправит планировщик, остальные отправляются сразу
+	var records []kafka_services.KeyedMessage
+	var sendIndexes []int // Индексы отправляемых сообщений в ids
+	for j, id := range ids {
+		res.Ids[indexes[j]] = int32(id)
+		if messages[j].Status == "scheduled" {
+			continue
+		}
+		records = append(records, kafkaMessage(id, messages[j]))
+		sendIndexes = append(sendIndexes, j)
+	}
+
+	// Отправка всего пакета в Kafka одним вызовом WriteMessages
+	errs := kafka_services.MessageErrors(s.kafkaProducer.SendMessages(ctx, records), len(records))
+	var sent, failed []int
+	for k, err := range errs {
+		j := sendIndexes[k]
+		if err == nil {
+			sent = append(sent, ids[j])
+			continue
+		}
+		failed = append(failed, ids[j])
+		res.Failures = append(res.Failures, &pb.BatchItemFailure{
+			Index: int32(indexes[j]),
+			Id:    int32(ids[j]),
+			Error: "failed to send message to Kafka",
+		})
+	}
+	if len(failed) > 0 {
+		log.Printf("Failed to send %d of %d batch messages to Kafka", len(failed), len(records))
+	}
+	s.updateStatuses(ctx, sent, "processed")
+	s.updateStatuses(ctx, failed, "failed")
+
+	return stream.SendAndClose(res)
+}
@@ -0,0 +1,100 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"go_micro_gRPS/internal/database"
+	"go_micro_gRPS/internal/kafka_services"
+	"log"
+)
+
+// deliveryQueueSize количество пакетов отчётов, ожидающих записи в БД; при заполнении очереди
+// writer асинхронного producer'а ждет её освобождения
+const deliveryQueueSize = 256
+
+// DeliveryRecorder записывает в БД результаты асинхронной отправки: сообщения, подтвержденные Kafka,
+// получают статус 'processed', как и при синхронной отправке, не доставленные после всех попыток -
+// 'failed' и могут быть отправлены повторно через RetryMessage.
+//
+// Отчёты приходят из горутины writer'а kafka-go, поэтому Record только ставит их в очередь,
+// а запись в БД выполняется в отдельной горутине и не задерживает отправку следующих пакетов.
+type DeliveryRecorder struct {
+	// completeDeliveries обновляет статус сообщений ids (database.CompleteDeliveries)
+	completeDeliveries func(ctx context.Context, ids []int, status string) error
+	reports            chan []kafka_services.DeliveryReport
+	done               chan struct{}
+}
+
+// NewDeliveryRecorder создаёт DeliveryRecorder и запускает запись отчётов в БД.
+func NewDeliveryRecorder(db *sql.DB) *DeliveryRecorder {
+	return newDeliveryRecorder(func(ctx context.Context, ids []int, status string) error {
+		return database.CompleteDeliveries(ctx, db, ids, status)
+	})
+}
+
+func newDeliveryRecorder(completeDeliveries func(ctx context.Context, ids []int, status string) error) *DeliveryRecorder {
+	r := &DeliveryRecorder{
+		completeDeliveries: completeDeliveries,
+		reports:            make(chan []kafka_services.DeliveryReport, deliveryQueueSize),
+		done:               make(chan struct{}),
+	}
+	go r.run()
+	return r
+}
+
+// Record ставит отчёты о доставке в очередь записи (kafka_services.ProducerConfig.OnDelivery).
+func (r *DeliveryRecorder) Record(reports []kafka_services.DeliveryReport) {
+	r.reports <- reports
+}
+
+// Close дожидается записи всех отчётов из очереди. Вызывается после закрытия producer'а,
+// когда новых отчётов больше не будет.
+func (r *DeliveryRecorder) Close() {
+	close(r.reports)
+	<-r.done
+}
+
+func (r *DeliveryRecorder) run() {
+	defer close(r.done)
+	for reports := range r.reports {
+		// Накопившиеся в очереди отчёты записываются вместе, чтобы при отставании БД
+		// количество запросов не росло вместе с количеством пакетов
+		for more := true; more; {
+			select {
+			case next, ok := <-r.reports:
+				if !ok {
+					more = false
+					break
+				}
+				reports = append(reports, next...)
+			default:
+				more = false
+			}
+		}
+		r.complete(reports)
+	}
+}
+
+func (r *DeliveryRecorder) complete(reports []kafka_services.DeliveryReport) {
+	var processed, failed []int
+	for _, report := range reports {
+		if report.Err != nil {
+			log.Printf("Error delivering message %d to Kafka: %v", report.ID, report.Err)
+			failed = append(failed, report.ID)
+			continue
+		}
+		processed = append(processed, report.ID)
+	}
+
+	// Отчёты не связаны с запросом, поэтому используется свой тайм-аут
+	ctx, cancel := context.WithTimeout(context.Background(), statusUpdateTimeout)
+	defer cancel()
+	for status, ids := range map[string][]int{"processed": processed, "failed": failed} {
+		if len(ids) == 0 {
+			continue
+		}
+		if err := r.completeDeliveries(ctx, ids, status); err != nil {
+			log.Printf("Error updating delivery status of %d messages: %v", len(ids), err)
+		}
+	}
+}
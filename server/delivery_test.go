@@ -0,0 +1,93 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"go_micro_gRPS/internal/kafka_services"
+)
+
+// completions записывает вызовы обновления статуса DeliveryRecorder.
+type completions struct {
+	mu      sync.Mutex
+	calls   []map[string][]int // Статусы и ID каждого вызова complete
+	started chan struct{}      // Сигнал о начале обновления статуса (если задан)
+	release chan struct{}      // Обновление статуса ждет закрытия канала (если задан)
+}
+
+func (c *completions) complete(_ context.Context, ids []int, status string) error {
+	if c.started != nil {
+		select {
+		case c.started <- struct{}{}:
+		default:
+		}
+	}
+	if c.release != nil {
+		<-c.release
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.calls = append(c.calls, map[string][]int{status: append([]int(nil), ids...)})
+	return nil
+}
+
+// byStatus объединяет ID всех вызовов по статусам.
+func (c *completions) byStatus() map[string][]int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	result := make(map[string][]int)
+	for _, call := range c.calls {
+		for status, ids := range call {
+			result[status] = append(result[status], ids...)
+		}
+	}
+	for _, ids := range result {
+		sort.Ints(ids)
+	}
+	return result
+}
+
+func TestDeliveryRecorderStatuses(t *testing.T) {
+	c := &completions{}
+	r := newDeliveryRecorder(c.complete)
+	deliveryErr := errors.New("kafka unavailable")
+	r.Record([]kafka_services.DeliveryReport{{ID: 1}, {ID: 2, Err: deliveryErr}, {ID: 3}})
+	r.Record([]kafka_services.DeliveryReport{{ID: 4, Err: deliveryErr}})
+	r.Record(nil)
+	// Close дожидается записи всех поставленных в очередь отчётов
+	r.Close()
+
+	want := map[string][]int{"processed": {1, 3}, "failed": {2, 4}}
+	if got := c.byStatus(); !reflect.DeepEqual(got, want) {
+		t.Errorf("статусы %v, ожидались %v", got, want)
+	}
+}
+
+func TestDeliveryRecorderBatchesQueuedReports(t *testing.T) {
+	c := &completions{started: make(chan struct{}, 1), release: make(chan struct{})}
+	r := newDeliveryRecorder(c.complete)
+
+	// Пока записывается первый отчёт, в очереди накапливаются следующие
+	r.Record([]kafka_services.DeliveryReport{{ID: 1}})
+	select {
+	case <-c.started:
+	case <-time.After(10 * time.Second):
+		t.Fatal("запись первого отчёта не началась")
+	}
+	for id := 2; id <= 5; id++ {
+		r.Record([]kafka_services.DeliveryReport{{ID: id}})
+	}
+	close(c.release)
+	r.Close()
+
+	// Накопившиеся отчёты записываются одним обновлением
+	want := []map[string][]int{{"processed": {1}}, {"processed": {2, 3, 4, 5}}}
+	if !reflect.DeepEqual(c.calls, want) {
+		t.Errorf("обновления статуса %v, ожидались %v", c.calls, want)
+	}
+}
@@ -0,0 +1,101 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"go_micro_gRPS/internal/apperrors"
+	"go_micro_gRPS/internal/kafka_services"
+	"go_micro_gRPS/internal/memconn"
+	pb "go_micro_gRPS/proto/go_micro_gRPC/proto"
+	"log"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+// NewGatewayHandler создаёт HTTP-обработчик REST API, сгенерированного из HTTP-аннотаций service.proto.
+// Запросы транскодируются в вызовы того же Server через внутренний gRPC-сервер в памяти с той же
+// цепочкой перехватчиков (журнал, аутентификация, лимиты), поэтому оба транспорта ведут себя одинаково.
+// При отмене ctx внутренний сервер и соединение закрываются.
+func NewGatewayHandler(ctx context.Context, db *sql.DB, producer *kafka_services.Producer, consumer *kafka_services.Consumer, opts ...Option) (http.Handler, error) {
+	o := newOptions(opts)
+
+	lis := memconn.Listen()
+	s := grpc.NewServer(o.grpcServerOptions()...)
+	pb.RegisterMessageServiceServer(s, o.newServer(db, producer, consumer))
+	go func() {
+		if err := s.Serve(lis); err != nil {
+			log.Printf("Ошибка внутреннего gRPC-сервера HTTP-шлюза: %v", err)
+		}
+	}()
+
+	conn, err := grpc.NewClient("passthrough:///gateway",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return lis.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		s.Stop()
+		return nil, err
+	}
+
+	go func() {
+		<-ctx.Done()
+		if err := conn.Close(); err != nil {
+			log.Printf("Ошибка закрытия соединения HTTP-шлюза: %v", err)
+		}
+		s.GracefulStop()
+	}()
+
+	mux := runtime.NewServeMux(
+		runtime.WithMarshalerOption(runtime.MIMEWildcard, &runtime.JSONPb{
+			MarshalOptions:   protojson.MarshalOptions{UseProtoNames: true, EmitUnpopulated: true},
+			UnmarshalOptions: protojson.UnmarshalOptions{DiscardUnknown: true},
+		}),
+		runtime.WithIncomingHeaderMatcher(gatewayIncomingHeader),
+		runtime.WithOutgoingHeaderMatcher(gatewayOutgoingHeader),
+		runtime.WithErrorHandler(gatewayErrorHandler),
+	)
+	if err := pb.RegisterMessageServiceHandler(ctx, mux, conn); err != nil {
+		conn.Close()
+		s.Stop()
+		return nil, err
+	}
+	return mux, nil
+}
+
+// gatewayIncomingHeader передаёт в метаданные gRPC ключ API, идентификатор запроса и ключ идемпотентности
+// в дополнение к стандартным заголовкам (Authorization передаётся шлюзом всегда).
+func gatewayIncomingHeader(key string) (string, bool) {
+	switch k := strings.ToLower(key); k {
+	case "x-api-key", RequestIDHeader, IdempotencyKeyHeader:
+		return k, true
+	}
+	return runtime.DefaultHeaderMatcher(key)
+}
+
+// gatewayOutgoingHeader возвращает клиенту идентификатор запроса (X-Request-Id)
+// и признак повтора идемпотентного запроса (Idempotent-Replayed).
+func gatewayOutgoingHeader(key string) (string, bool) {
+	switch key {
+	case RequestIDHeader, IdempotentReplayedHeader:
+		return http.CanonicalHeaderKey(key), true
+	}
+	return "", false
+}
+
+// gatewayErrorHandler отвечает на ошибку в том же формате, что и apperrors.WriteHTTPError.
+func gatewayErrorHandler(ctx context.Context, _ *runtime.ServeMux, _ runtime.Marshaler, w http.ResponseWriter, _ *http.Request, err error) {
+	if md, ok := runtime.ServerMetadataFromContext(ctx); ok {
+		if values := md.HeaderMD.Get(RequestIDHeader); len(values) > 0 {
+			w.Header().Set(http.CanonicalHeaderKey(RequestIDHeader), values[0])
+		}
+	}
+	apperrors.WriteHTTPError(w, apperrors.FromGRPC(err))
+}
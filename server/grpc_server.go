@@ -3,59 +3,215 @@ package server
 import (
 	"context"
 	"database/sql"
+	"errors"
+	"fmt"
+	"go_micro_gRPS/internal/apperrors"           // Единая классификация ошибок для gRPC и HTTP
+	"go_micro_gRPS/internal/auth"                // Аутентифицированный отправитель сообщения
 	"go_micro_gRPS/internal/database"            // Пакет для работы с базой данных
 	"go_micro_gRPS/internal/kafka_services"      // Пакет для взаимодействия с Kafka
+	"go_micro_gRPS/internal/models"              // Модели и валидация сообщений
 	pb "go_micro_gRPS/proto/go_micro_gRPC/proto" // Импорт сгенерированных протофайлов
 	"google.golang.org/grpc"                     // Библиотека для работы с gRPC
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/protobuf/types/known/timestamppb"
 	"log"
 	"net"
+	"time"
 )
 
+// statusUpdateTimeout время на обновление статуса сообщений после отправки в Kafka
+const statusUpdateTimeout = 5 * time.Second
+
 // Server Структура сервера, реализующая методы gRPC-сервиса
 type Server struct {
 	pb.UnimplementedMessageServiceServer                          // Встраивание gRPC-сервера с пустой реализацией
 	db                                   *sql.DB                  // Подключение к базе данных
 	kafkaProducer                        *kafka_services.Producer // Kafka-продюсер для отправки сообщений
+	kafkaConsumer                        *kafka_services.Consumer // Kafka-консьюмер, сообщения которого рассылаются подписчикам
+	idempotencyRetention                 time.Duration            // Время хранения ключей идемпотентности
+	defaultTopic                         string                   // Топик для сообщений без явно указанного топика
+	topics                               map[string]bool          // Топики, в которые разрешена отправка
 }
 
 // SendMessage Метод SendMessage принимает сообщение, сохраняет его в БД и отправляет в Kafka.
+// При асинхронном producer'е сообщение ставится в очередь отправки и остается в статусе 'pending'
+// до подтверждения Kafka, в том числе с ключом идемпотентности; пакеты и потоки отправляются
+// синхронно, так как их ответ зависит от результата отправки.
 func (s *Server) SendMessage(ctx context.Context, req *pb.MessageRequest) (*pb.MessageResponse, error) {
+	// Проверка содержимого, ключа, атрибутов и времени доставки сообщения
+	msg, err := s.messageFromRequest(req, auth.SubjectFromContext(ctx))
+	if err != nil {
+		return nil, apperrors.ToGRPC(err)
+	}
+
+	// Запрос с ключом идемпотентности сохраняется и отправляется не более одного раза
+	key, err := idempotencyKey(ctx)
+	if err != nil {
+		return nil, apperrors.ToGRPC(err)
+	}
+	if key != "" {
+		id, status, err := s.sendMessageIdempotent(ctx, req, msg, key)
+		if err != nil {
+			log.Printf("Error sending message with idempotency key: %v", err)
+			return nil, apperrors.ToGRPC(err)
+		}
+		return idempotentResponse(id, status, msg), nil
+	}
+
 	// Сохранение сообщения в базе данных
-	id, err := database.SaveMessage(s.db, req.Content)
+	id, err := database.SaveMessage(ctx, s.db, msg)
 	if err != nil {
 		// Логирование ошибки сохранения сообщения в БД
 		log.Printf("Error saving message to database: %v", err)
-		return nil, err
+		return nil, apperrors.ToGRPC(err)
 	}
 
-	// Формирование сообщения для отправки в Kafka
-	message := map[string]interface{}{
-		"id":      id,
-		"content": req.Content,
+	// Отложенное сообщение отправит планировщик
+	if msg.Status == "scheduled" {
+		return sendResponse(id, msg), nil
 	}
-	// Отправка сообщения в Kafka
-	err = s.kafkaProducer.SendMessage(ctx, "default-key", message)
+
+	// В асинхронном режиме ответ возвращается сразу, статус обновит отчёт о доставке (DeliveryRecorder)
+	if s.kafkaProducer.Async() {
+		if err := s.kafkaProducer.Publish(ctx, kafkaMessage(id, msg)); err != nil {
+			log.Printf("Error queueing message for Kafka: %v", err)
+			s.updateStatuses(ctx, []int{id}, "failed")
+			return nil, apperrors.ToGRPC(err)
+		}
+		res := sendResponse(id, msg)
+		res.Status = "Message accepted for delivery"
+		return res, nil
+	}
+
+	// Отправка сообщения в Kafka с ключом и атрибутами в заголовках
+	err = s.kafkaProducer.SendMessage(ctx, kafkaMessage(id, msg))
 	if err != nil {
 		// Логирование ошибки отправки сообщения в Kafka
 		log.Printf("Error sending message to Kafka: %v", err)
-		return nil, err
+		// Обновляем статус сообщения на 'failed' при ошибке отправки
+		s.updateStatuses(ctx, []int{id}, "failed")
+		return nil, apperrors.ToGRPC(err)
 	}
 
+	// Обновляем статус сообщения на 'processed'
+	s.updateStatuses(ctx, []int{id}, "processed")
+
 	// Возврат ответа с подтверждением отправки
+	return sendResponse(id, msg), nil
+}
+
+// messageFromRequest формирует и проверяет сообщение из запроса. Сообщение с временем доставки
+// в будущем получает статус 'scheduled' и отправляется в Kafka планировщиком.
+// Срок жизни (ttl) отсчитывается от времени запроса.
+func (s *Server) messageFromRequest(req *pb.MessageRequest, createdBy string) (models.Message, error) {
+	msg := models.Message{
+		Content:     req.Content,
+		CreatedBy:   createdBy,
+		Key:         req.Key,
+		Attributes:  req.Attributes,
+		ContentType: req.ContentType,
+		Priority:    req.Priority,
+	}
+
+	topic, err := s.resolveTopic(req.Topic)
+	if err != nil {
+		return msg, err
+	}
+	msg.Topic = topic
+
+	now := time.Now()
+	switch schedule := req.Schedule.(type) {
+	case *pb.MessageRequest_DeliverAt:
+		if err := schedule.DeliverAt.CheckValid(); err != nil {
+			return msg, apperrors.InvalidArgument("deliver_at", "invalid delivery time")
+		}
+		msg.DeliverAt = schedule.DeliverAt.AsTime()
+	case *pb.MessageRequest_Delay:
+		if err := schedule.Delay.CheckValid(); err != nil || schedule.Delay.AsDuration() < 0 {
+			return msg, apperrors.InvalidArgument("delay", "delay must be a non-negative duration")
+		}
+		msg.DeliverAt = now.Add(schedule.Delay.AsDuration())
+	}
+	if req.Ttl != nil {
+		ttl := req.Ttl.AsDuration()
+		if err := req.Ttl.CheckValid(); err != nil || ttl <= 0 || ttl > models.MaxTTL {
+			return msg, apperrors.InvalidArgument("ttl", "ttl must be a positive duration")
+		}
+		msg.ExpiresAt = now.Add(ttl)
+	}
+
+	if err := models.ValidateMessage(msg); err != nil {
+		return msg, err
+	}
+	if msg.Priority == "" {
+		msg.Priority = models.PriorityNormal
+	}
+	if msg.Scheduled(now) {
+		msg.Status = "scheduled"
+	}
+	return msg, nil
+}
+
+// resolveTopic проверяет топик запроса по списку разрешенных; пустой топик заменяется топиком по умолчанию.
+func (s *Server) resolveTopic(topic string) (string, error) {
+	if topic == "" {
+		return s.defaultTopic, nil
+	}
+	if !s.topics[topic] {
+		return "", apperrors.InvalidArgument("topic", fmt.Sprintf("topic %q is not allowed", topic))
+	}
+	return topic, nil
+}
+
+// sendResponse формирует ответ на отправку сообщения.
+func sendResponse(id int, msg models.Message) *pb.MessageResponse {
+	status := "Message sent successfully"
+	if msg.Status == "scheduled" {
+		status = "Message scheduled for " + msg.DeliverAt.UTC().Format(time.RFC3339)
+	}
 	return &pb.MessageResponse{
-		Status: "Message sent successfully",
+		Status: status,
 		Id:     int32(id), // Возвращаем ID сохраненного сообщения
-	}, nil
+	}
+}
+
+// kafkaMessage формирует запись Kafka для сохраненного сообщения.
+func kafkaMessage(id int, msg models.Message) kafka_services.KeyedMessage {
+	return kafka_services.KeyedMessage{
+		ID:       id,
+		Topic:    msg.Topic,
+		Priority: msg.Priority,
+		Key:      msg.Key,
+		Headers:  msg.Headers(),
+		Message: map[string]interface{}{
+			"id":      id,
+			"content": msg.Content,
+		},
+	}
+}
+
+// updateStatuses обновляет статус сообщений после попытки отправки в Kafka. Обновление выполняется
+// и после отмены запроса клиентом (с собственным тайм-аутом), так как отправка уже состоялась;
+// ошибка только логируется.
+func (s *Server) updateStatuses(ctx context.Context, ids []int, status string) {
+	if len(ids) == 0 {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), statusUpdateTimeout)
+	defer cancel()
+	if err := database.UpdateMessagesStatus(ctx, s.db, ids, status); err != nil {
+		log.Printf("Error updating message status: %v", err)
+	}
 }
 
 // GetProcessedMessages Метод GetProcessedMessages возвращает количество обработанных сообщений.
 func (s *Server) GetProcessedMessages(ctx context.Context, req *pb.EmptyRequest) (*pb.MessageStats, error) {
 	// Получение количества обработанных сообщений из БД
-	count, err := database.GetProcessedMessageCount(s.db)
+	count, err := database.GetProcessedMessageCount(ctx, s.db)
 	if err != nil {
 		// Логирование ошибки при получении статистики
 		log.Printf("Error getting processed message count: %v", err)
-		return nil, err
+		return nil, apperrors.ToGRPC(err)
 	}
 
 	// Возврат статистики обработанных сообщений
@@ -64,8 +220,79 @@ func (s *Server) GetProcessedMessages(ctx context.Context, req *pb.EmptyRequest)
 	}, nil
 }
 
+// SubscribeMessages Метод SubscribeMessages отправляет клиенту сообщения, прочитанные из Kafka, по мере их поступления.
+func (s *Server) SubscribeMessages(req *pb.SubscribeRequest, stream pb.MessageService_SubscribeMessagesServer) error {
+	if req.BufferSize < 0 || req.BufferSize > kafka_services.MaxSubscriberBuffer {
+		return apperrors.ToGRPC(apperrors.InvalidArgument("buffer_size",
+			fmt.Sprintf("buffer_size must be between 0 and %d", kafka_services.MaxSubscriberBuffer)))
+	}
+	var from *kafka_services.ReplayPosition
+	if req.StartOffset != nil {
+		if req.StartTopic == "" {
+			return apperrors.ToGRPC(apperrors.InvalidArgument("start_topic", "start_topic is required with start_offset"))
+		}
+		from = &kafka_services.ReplayPosition{Topic: req.StartTopic, Partition: int(req.StartPartition), Offset: *req.StartOffset}
+	}
+
+	// Регистрация подписчика, при необходимости с воспроизведением истории партиции с заданного смещения
+	sub, err := s.kafkaConsumer.Subscribe(from, int(req.BufferSize))
+	if err != nil {
+		return apperrors.ToGRPC(apperrors.Unavailable("kafka", "kafka consumer is stopped"))
+	}
+	defer s.kafkaConsumer.Unsubscribe(sub)
+
+	for {
+		select {
+		case <-stream.Context().Done():
+			// Клиент отключился или истёк дедлайн
+			return stream.Context().Err()
+		case msg, ok := <-sub.Messages():
+			if !ok {
+				// Подписка закрыта consumer'ом: подписчик отстал или чтение из Kafka остановлено
+				if errors.Is(sub.Err(), kafka_services.ErrSlowSubscriber) {
+					return apperrors.ToGRPC(apperrors.ResourceExhausted("subscriber is too slow, subscription closed", 0))
+				}
+				return apperrors.ToGRPC(apperrors.Unavailable("kafka", "kafka consumer is stopped"))
+			}
+
+			err := stream.Send(&pb.ConsumedMessage{
+				Topic:     msg.Topic,
+				Partition: int32(msg.Partition),
+				Offset:    msg.Offset,
+				Key:       msg.Key,
+				Value:     msg.Value,
+				Time:      timestamppb.New(msg.Time),
+				Headers:   msg.Headers,
+				Priority:  msg.Priority,
+			})
+			if err != nil {
+				log.Printf("Error sending message to subscriber: %v", err)
+				return err
+			}
+		}
+	}
+}
+
+// newServer создаёт реализацию MessageService с настройками из опций.
+func (o *options) newServer(db *sql.DB, producer *kafka_services.Producer, consumer *kafka_services.Consumer) *Server {
+	topics := make(map[string]bool, len(o.topics))
+	for _, topic := range o.topics {
+		topics[topic] = true
+	}
+	return &Server{
+		db:                   db,
+		kafkaProducer:        producer,
+		kafkaConsumer:        consumer,
+		idempotencyRetention: o.idempotencyRetention,
+		defaultTopic:         o.defaultTopic,
+		topics:               topics,
+	}
+}
+
 // StartGRPCServer Запуск gRPC-сервера и регистрация сервисов.
-func StartGRPCServer(db *sql.DB, producer *kafka_services.Producer) {
+// При отмене ctx сервер переводит health-статус в NOT_SERVING и корректно завершает работу.
+// Опции позволяют настроить журнал вызовов и добавить собственные перехватчики.
+func StartGRPCServer(ctx context.Context, db *sql.DB, producer *kafka_services.Producer, consumer *kafka_services.Consumer, brokers []string, opts ...Option) {
 	// Прослушивание порта 50051 для входящих соединений
 	lis, err := net.Listen("tcp", ":50051")
 	if err != nil {
@@ -73,10 +300,23 @@ func StartGRPCServer(db *sql.DB, producer *kafka_services.Producer) {
 		log.Fatalf("Failed to listen: %v", err)
 	}
 
-	// Создание экземпляра gRPC-сервера
-	s := grpc.NewServer()
+	o := newOptions(opts)
+
+	// Создание экземпляра gRPC-сервера с цепочкой перехватчиков
+	s := grpc.NewServer(append(o.grpcServerOptions(), o.transportCredentials()...)...)
 	// Регистрация сервера сообщений, реализующего MessageServiceServer
-	pb.RegisterMessageServiceServer(s, &Server{db: db, kafkaProducer: producer})
+	pb.RegisterMessageServiceServer(s, o.newServer(db, producer, consumer))
+
+	// Регистрация health-сервиса, статус которого определяется доступностью PostgreSQL и Kafka
+	healthChecker := NewHealthChecker(db, brokers)
+	healthpb.RegisterHealthServer(s, healthChecker.Server())
+	go healthChecker.Run(ctx)
+
+	go func() {
+		<-ctx.Done()
+		log.Println("Stopping gRPC Server...")
+		s.GracefulStop()
+	}()
 
 	log.Println("Starting gRPC Server on port 50051...")
 	// Запуск gRPC-сервера для обслуживания входящих запросов
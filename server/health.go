@@ -0,0 +1,111 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"go_micro_gRPS/internal/kafka_services"
+	pb "go_micro_gRPS/proto/go_micro_gRPC/proto"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"log"
+	"time"
+)
+
+const (
+	// healthCheckInterval периодичность проверки зависимостей
+	healthCheckInterval = 10 * time.Second
+	// healthCheckTimeout максимальное время одной проверки зависимости
+	healthCheckTimeout = 3 * time.Second
+
+	// Имена сервисов в health-сервисе. Пустое имя означает общее состояние сервера.
+	postgresHealthService = "postgres"
+	kafkaHealthService    = "kafka"
+)
+
+// HealthChecker периодически проверяет PostgreSQL и Kafka и обновляет статусы grpc.health.v1.Health.
+// Общий статус и статус MessageService равны SERVING, только если доступны все зависимости.
+type HealthChecker struct {
+	health  *health.Server
+	db      *sql.DB
+	brokers []string
+
+	// Последние статусы зависимостей, чтобы логировать только изменения
+	statuses map[string]healthpb.HealthCheckResponse_ServingStatus
+}
+
+// NewHealthChecker создаёт HealthChecker. До первой проверки все сервисы имеют статус NOT_SERVING.
+func NewHealthChecker(db *sql.DB, brokers []string) *HealthChecker {
+	h := &HealthChecker{
+		health:   health.NewServer(),
+		db:       db,
+		brokers:  brokers,
+		statuses: make(map[string]healthpb.HealthCheckResponse_ServingStatus),
+	}
+	for _, service := range []string{"", pb.MessageService_ServiceDesc.ServiceName, postgresHealthService, kafkaHealthService} {
+		h.health.SetServingStatus(service, healthpb.HealthCheckResponse_NOT_SERVING)
+	}
+	return h
+}
+
+// Server возвращает реализацию health-сервиса для регистрации в gRPC-сервере.
+func (h *HealthChecker) Server() healthpb.HealthServer {
+	return h.health
+}
+
+// Run выполняет проверки до отмены контекста, после чего переводит все сервисы в NOT_SERVING.
+func (h *HealthChecker) Run(ctx context.Context) {
+	ticker := time.NewTicker(healthCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		h.check(ctx)
+
+		select {
+		case <-ctx.Done():
+			// Shutdown переводит все сервисы в NOT_SERVING и уведомляет подписчиков Watch
+			h.health.Shutdown()
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// check проверяет все зависимости и обновляет статусы.
+func (h *HealthChecker) check(ctx context.Context) {
+	dbStatus := h.probe(postgresHealthService, func() error {
+		pingCtx, cancel := context.WithTimeout(ctx, healthCheckTimeout)
+		defer cancel()
+		return h.db.PingContext(pingCtx)
+	})
+	kafkaStatus := h.probe(kafkaHealthService, func() error {
+		return kafka_services.CheckBrokers(h.brokers, healthCheckTimeout)
+	})
+
+	overall := healthpb.HealthCheckResponse_SERVING
+	if dbStatus != healthpb.HealthCheckResponse_SERVING || kafkaStatus != healthpb.HealthCheckResponse_SERVING {
+		overall = healthpb.HealthCheckResponse_NOT_SERVING
+	}
+	h.health.SetServingStatus("", overall)
+	h.health.SetServingStatus(pb.MessageService_ServiceDesc.ServiceName, overall)
+}
+
+// probe выполняет проверку одной зависимости и устанавливает её статус.
+func (h *HealthChecker) probe(service string, check func() error) healthpb.HealthCheckResponse_ServingStatus {
+	servingStatus := healthpb.HealthCheckResponse_SERVING
+	err := check()
+	if err != nil {
+		servingStatus = healthpb.HealthCheckResponse_NOT_SERVING
+	}
+
+	if prev, ok := h.statuses[service]; !ok || prev != servingStatus {
+		if err != nil {
+			log.Printf("Health check %s failed: %v", service, err)
+		} else {
+			log.Printf("Health check %s: %s", service, servingStatus)
+		}
+		h.statuses[service] = servingStatus
+	}
+
+	h.health.SetServingStatus(service, servingStatus)
+	return servingStatus
+}
@@ -0,0 +1,194 @@
+package server
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"go_micro_gRPS/internal/apperrors"
+	"go_micro_gRPS/internal/database"
+	"go_micro_gRPS/internal/models"
+	"go_micro_gRPS/internal/ratelimit"
+	pb "go_micro_gRPS/proto/go_micro_gRPC/proto"
+	"log"
+	"sort"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+const (
+	// IdempotencyKeyHeader ключ метаданных (и HTTP-заголовок Idempotency-Key) с ключом идемпотентности
+	IdempotencyKeyHeader = "idempotency-key"
+	// IdempotentReplayedHeader заголовок ответа, выставляемый при возврате результата исходного запроса
+	IdempotentReplayedHeader = "idempotent-replayed"
+
+	// maxIdempotencyKeyLength максимальная длина ключа идемпотентности
+	maxIdempotencyKeyLength = 255
+	// defaultIdempotencyRetention время хранения ключей идемпотентности по умолчанию
+	defaultIdempotencyRetention = 24 * time.Hour
+	// idempotentSendTimeout время на завершение отправки с ключом идемпотентности после отключения клиента
+	idempotentSendTimeout = 30 * time.Second
+	// idempotencyCleanupInterval период удаления истекших ключей идемпотентности
+	idempotencyCleanupInterval = 10 * time.Minute
+)
+
+// WithIdempotencyRetention задаёт время хранения ключей идемпотентности (по умолчанию 24 часа).
+// Неположительное значение не применяется: с ним каждый повтор считался бы новым запросом.
+func WithIdempotencyRetention(retention time.Duration) Option {
+	return func(o *options) {
+		if retention > 0 {
+			o.idempotencyRetention = retention
+		}
+	}
+}
+
+// idempotencyKey возвращает ключ идемпотентности из метаданных запроса или пустую строку.
+func idempotencyKey(ctx context.Context) (string, error) {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return "", nil
+	}
+	values := md.Get(IdempotencyKeyHeader)
+	if len(values) == 0 || values[0] == "" {
+		return "", nil
+	}
+	if len(values[0]) > maxIdempotencyKeyLength {
+		return "", apperrors.InvalidArgument(IdempotencyKeyHeader, "idempotency key is too long")
+	}
+	return values[0], nil
+}
+
+// sendMessageIdempotent сохраняет и отправляет сообщение не более одного раза для ключа key
+// и возвращает ID и статус сообщения. Ключи принадлежат клиенту (ratelimit.ClientID): без
+// аутентификации клиенты различаются по сертификату mTLS или адресу, а не делят одно пространство ключей.
+// Отправка выполняется в контексте, не зависящем от отмены запроса: клиент, прервавший запрос
+// по тайм-ауту, при повторе получит результат исходного запроса, а не дубликат.
+func (s *Server) sendMessageIdempotent(ctx context.Context, req *pb.MessageRequest, msg models.Message, key string) (int, string, error) {
+	sendCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), idempotentSendTimeout)
+	defer cancel()
+
+	idempotencyKey := database.IdempotencyKey{
+		Principal:   ratelimit.ClientID(ctx),
+		Key:         key,
+		RequestHash: requestHash(req, msg),
+		Retention:   s.idempotencyRetention,
+	}
+	id, status, duplicate, err := database.SaveMessageIdempotent(sendCtx, s.db, msg, idempotencyKey)
+	if errors.Is(err, database.ErrIdempotencyKeyReused) {
+		return 0, "", apperrors.InvalidArgument(IdempotencyKeyHeader, "idempotency key was already used for a different request")
+	}
+	if err != nil {
+		return 0, "", err
+	}
+
+	if duplicate {
+		log.Printf("Idempotent replay of message %d with status %s (request_id=%s)", id, status, RequestIDFromContext(ctx))
+		if err := grpc.SetHeader(ctx, metadata.Pairs(IdempotentReplayedHeader, "true")); err != nil {
+			log.Printf("Error setting idempotent replay header: %v", err)
+		}
+		return id, status, nil
+	}
+
+	// Отложенное сообщение отправит планировщик
+	if status == "scheduled" {
+		return id, status, nil
+	}
+
+	// Ключ уже закреплен за сообщением, поэтому при ошибке отправки повтор запроса вернет его со статусом 'failed'
+	if s.kafkaProducer.Async() {
+		// Сообщение остается в статусе 'pending', пока его не обновит отчёт о доставке (DeliveryRecorder);
+		// повтор запроса до этого получит ответ о принятии к доставке
+		if err := s.kafkaProducer.Publish(sendCtx, kafkaMessage(id, msg)); err != nil {
+			s.updateStatuses(sendCtx, []int{id}, "failed")
+			return 0, "", err
+		}
+		return id, "pending", nil
+	}
+	if err := s.kafkaProducer.SendMessage(sendCtx, kafkaMessage(id, msg)); err != nil {
+		s.updateStatuses(sendCtx, []int{id}, "failed")
+		return 0, "", err
+	}
+	s.updateStatuses(sendCtx, []int{id}, "processed")
+	return id, "processed", nil
+}
+
+// idempotentResponse формирует ответ на запрос с ключом идемпотентности по текущему статусу сообщения.
+func idempotentResponse(id int, status string, msg models.Message) *pb.MessageResponse {
+	res := sendResponse(id, msg)
+	switch status {
+	case "pending":
+		res.Status = "Message accepted for delivery"
+	case "failed":
+		res.Status = "Message delivery failed, use RetryMessage to resend"
+	case "expired":
+		res.Status = "Message expired before delivery"
+	}
+	return res
+}
+
+// requestHash вычисляет хеш полей сообщения, по которому определяется повторное использование ключа
+// идемпотентности для другого запроса. Задержка и срок жизни берутся из запроса, а не из msg:
+// время доставки и истечения в msg отсчитываются от момента запроса и различаются у повторов.
+func requestHash(req *pb.MessageRequest, msg models.Message) string {
+	h := sha256.New()
+	write := func(value string) {
+		h.Write([]byte(value))
+		h.Write([]byte{0})
+	}
+	write(msg.Content)
+	write(msg.Key)
+	write(msg.ContentType)
+	write(msg.Topic)
+	write(msg.Priority)
+	switch schedule := req.Schedule.(type) {
+	case *pb.MessageRequest_DeliverAt:
+		write("deliver_at")
+		write(msg.DeliverAt.UTC().Format(time.RFC3339Nano))
+	case *pb.MessageRequest_Delay:
+		write("delay")
+		write(schedule.Delay.AsDuration().String())
+	default:
+		write("")
+	}
+	write(req.GetTtl().AsDuration().String())
+
+	names := make([]string, 0, len(msg.Attributes))
+	for name := range msg.Attributes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		write(name)
+		write(msg.Attributes[name])
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// CleanupIdempotencyKeys периодически удаляет ключи идемпотентности старше retention до отмены контекста.
+// Неположительный retention заменяется значением по умолчанию.
+func CleanupIdempotencyKeys(ctx context.Context, db *sql.DB, retention time.Duration) {
+	if retention <= 0 {
+		retention = defaultIdempotencyRetention
+	}
+	ticker := time.NewTicker(idempotencyCleanupInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			deleted, err := database.DeleteExpiredIdempotencyKeys(ctx, db, retention)
+			if err != nil {
+				log.Printf("Error deleting expired idempotency keys: %v", err)
+				continue
+			}
+			if deleted > 0 {
+				log.Printf("Deleted %d expired idempotency keys", deleted)
+			}
+		}
+	}
+}
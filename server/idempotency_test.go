@@ -0,0 +1,71 @@
+package server
+
+import (
+	"testing"
+	"time"
+
+	"go_micro_gRPS/internal/models"
+	pb "go_micro_gRPS/proto/go_micro_gRPC/proto"
+
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func TestRequestHash(t *testing.T) {
+	s := &Server{defaultTopic: "messages", topics: map[string]bool{"messages": true}}
+	deliverAt := time.Now().Add(time.Hour).Truncate(time.Second)
+	base := func() *pb.MessageRequest { return &pb.MessageRequest{Content: "hello", Key: "order-42"} }
+	hash := func(req *pb.MessageRequest) string {
+		t.Helper()
+		msg, err := s.messageFromRequest(req, "svc")
+		if err != nil {
+			t.Fatalf("messageFromRequest: %v", err)
+		}
+		return requestHash(req, msg)
+	}
+
+	tests := []struct {
+		name   string
+		modify func(req *pb.MessageRequest)
+		same   bool // Хеш совпадает с хешем base
+	}{
+		{"тот же запрос", func(*pb.MessageRequest) {}, true},
+		{"явный топик по умолчанию", func(r *pb.MessageRequest) { r.Topic = "messages" }, true},
+		{"явный приоритет normal", func(r *pb.MessageRequest) { r.Priority = models.PriorityNormal }, true},
+		{"другое содержимое", func(r *pb.MessageRequest) { r.Content = "bye" }, false},
+		{"другой приоритет", func(r *pb.MessageRequest) { r.Priority = models.PriorityHigh }, false},
+		{"атрибут", func(r *pb.MessageRequest) { r.Attributes = map[string]string{"type": "order"} }, false},
+		{"задержка", func(r *pb.MessageRequest) { r.Schedule = &pb.MessageRequest_Delay{Delay: durationpb.New(time.Minute)} }, false},
+		{"время доставки", func(r *pb.MessageRequest) {
+			r.Schedule = &pb.MessageRequest_DeliverAt{DeliverAt: timestamppb.New(deliverAt)}
+		}, false},
+		{"срок жизни", func(r *pb.MessageRequest) { r.Ttl = durationpb.New(time.Hour) }, false},
+	}
+	want := hash(base())
+	for _, tt := range tests {
+		req := base()
+		tt.modify(req)
+		if got := hash(req); (got == want) != tt.same {
+			t.Errorf("%s: совпадение хешей %v, ожидалось %v", tt.name, got == want, tt.same)
+		}
+	}
+
+	// Повтор запроса с задержкой и сроком жизни позже дает тот же хеш, хотя время доставки
+	// и истечения сообщения отсчитываются от момента запроса
+	withSchedule := func() *pb.MessageRequest {
+		req := base()
+		req.Schedule = &pb.MessageRequest_Delay{Delay: durationpb.New(time.Minute)}
+		req.Ttl = durationpb.New(time.Hour)
+		return req
+	}
+	first := hash(withSchedule())
+	time.Sleep(2 * time.Millisecond)
+	if hash(withSchedule()) != first {
+		t.Error("хеш повтора запроса с задержкой и сроком жизни изменился")
+	}
+	other := withSchedule()
+	other.Ttl = durationpb.New(2 * time.Hour)
+	if hash(other) == first {
+		t.Error("хеш не учитывает срок жизни")
+	}
+}
@@ -0,0 +1,216 @@
+package server
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/hex"
+	"go_micro_gRPS/internal/ratelimit"
+	"log"
+	"regexp"
+	"runtime/debug"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// RequestIDHeader ключ метаданных с идентификатором запроса
+const RequestIDHeader = "x-request-id"
+
+// validRequestID допустимый идентификатор запроса клиента; иначе идентификатор генерируется,
+// чтобы в журнал и метаданные ответа не попадали произвольные строки
+var validRequestID = regexp.MustCompile(`^[A-Za-z0-9._-]{1,128}$`)
+
+type requestIDKey struct{}
+
+// RequestIDFromContext возвращает идентификатор текущего запроса или пустую строку.
+func RequestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// Option настройка gRPC-сервера.
+type Option func(*options)
+
+type options struct {
+	accessLog     bool
+	unary         []grpc.UnaryServerInterceptor
+	stream        []grpc.StreamServerInterceptor
+	serverOptions []grpc.ServerOption
+	tlsConfig     *tls.Config
+
+	idempotencyRetention time.Duration
+	defaultTopic         string
+	topics               []string
+}
+
+func newOptions(opts []Option) *options {
+	o := &options{accessLog: true, idempotencyRetention: defaultIdempotencyRetention}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// WithAccessLog включает или отключает журнал вызовов (по умолчанию включен).
+func WithAccessLog(enabled bool) Option {
+	return func(o *options) { o.accessLog = enabled }
+}
+
+// WithUnaryInterceptors добавляет unary-перехватчики в конец цепочки, после встроенных.
+func WithUnaryInterceptors(interceptors ...grpc.UnaryServerInterceptor) Option {
+	return func(o *options) { o.unary = append(o.unary, interceptors...) }
+}
+
+// WithStreamInterceptors добавляет stream-перехватчики в конец цепочки, после встроенных.
+func WithStreamInterceptors(interceptors ...grpc.StreamServerInterceptor) Option {
+	return func(o *options) { o.stream = append(o.stream, interceptors...) }
+}
+
+// WithServerOptions передаёт дополнительные опции в grpc.NewServer.
+func WithServerOptions(serverOptions ...grpc.ServerOption) Option {
+	return func(o *options) { o.serverOptions = append(o.serverOptions, serverOptions...) }
+}
+
+// WithTopics задаёт список топиков, в которые клиенты могут отправлять сообщения, и топик для сообщений без топика.
+// Без этой опции топик в запросе не принимается и все сообщения отправляются в топик producer'а по умолчанию.
+func WithTopics(defaultTopic string, topics []string) Option {
+	return func(o *options) {
+		o.defaultTopic = defaultTopic
+		o.topics = topics
+	}
+}
+
+// WithTLS включает TLS для внешнего gRPC-порта. Внутренний сервер HTTP-шлюза TLS не использует.
+func WithTLS(cfg *tls.Config) Option {
+	return func(o *options) { o.tlsConfig = cfg }
+}
+
+// transportCredentials возвращает опции транспорта внешнего gRPC-порта.
+func (o *options) transportCredentials() []grpc.ServerOption {
+	if o.tlsConfig == nil {
+		return nil
+	}
+	return []grpc.ServerOption{grpc.Creds(credentials.NewTLS(o.tlsConfig))}
+}
+
+// grpcServerOptions собирает опции grpc.NewServer с цепочкой перехватчиков.
+// Порядок: request ID -> журнал вызовов -> восстановление после паники -> пользовательские перехватчики,
+// поэтому журнал видит итоговый код ответа, в том числе для перехваченной паники.
+func (o *options) grpcServerOptions() []grpc.ServerOption {
+	unary := []grpc.UnaryServerInterceptor{RequestIDUnaryInterceptor}
+	stream := []grpc.StreamServerInterceptor{RequestIDStreamInterceptor}
+	if o.accessLog {
+		unary = append(unary, LoggingUnaryInterceptor)
+		stream = append(stream, LoggingStreamInterceptor)
+	}
+	unary = append(unary, RecoveryUnaryInterceptor)
+	stream = append(stream, RecoveryStreamInterceptor)
+
+	serverOptions := []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(append(unary, o.unary...)...),
+		grpc.ChainStreamInterceptor(append(stream, o.stream...)...),
+	}
+	return append(serverOptions, o.serverOptions...)
+}
+
+// RecoveryUnaryInterceptor перехватывает панику в обработчике и возвращает codes.Internal вместо падения процесса.
+func RecoveryUnaryInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp interface{}, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = recoverPanic(ctx, info.FullMethod, r)
+		}
+	}()
+	return handler(ctx, req)
+}
+
+// RecoveryStreamInterceptor перехватывает панику в потоковом обработчике.
+func RecoveryStreamInterceptor(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = recoverPanic(ss.Context(), info.FullMethod, r)
+		}
+	}()
+	return handler(srv, ss)
+}
+
+func recoverPanic(ctx context.Context, method string, r interface{}) error {
+	log.Printf("Panic in %s (request_id=%s): %v\n%s", method, RequestIDFromContext(ctx), r, debug.Stack())
+	return status.Error(codes.Internal, "internal error")
+}
+
+// RequestIDUnaryInterceptor берёт идентификатор запроса из метаданных x-request-id (до 128 символов
+// A-Z, a-z, 0-9, '.', '_', '-') или генерирует новый,
+// сохраняет его в контексте и возвращает клиенту в заголовке ответа.
+func RequestIDUnaryInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	ctx, id := withRequestID(ctx)
+	if err := grpc.SetHeader(ctx, metadata.Pairs(RequestIDHeader, id)); err != nil {
+		log.Printf("Error setting request id header: %v", err)
+	}
+	return handler(ctx, req)
+}
+
+// RequestIDStreamInterceptor аналог RequestIDUnaryInterceptor для потоковых методов.
+func RequestIDStreamInterceptor(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	ctx, id := withRequestID(ss.Context())
+	if err := ss.SetHeader(metadata.Pairs(RequestIDHeader, id)); err != nil {
+		log.Printf("Error setting request id header: %v", err)
+	}
+	return handler(srv, &contextStream{ServerStream: ss, ctx: ctx})
+}
+
+func withRequestID(ctx context.Context) (context.Context, string) {
+	var id string
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get(RequestIDHeader); len(values) > 0 && validRequestID.MatchString(values[0]) {
+			id = values[0]
+		}
+	}
+	if id == "" {
+		id = newRequestID()
+	}
+	return context.WithValue(ctx, requestIDKey{}, id), id
+}
+
+func newRequestID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return time.Now().Format("20060102150405.000000000")
+	}
+	return hex.EncodeToString(b)
+}
+
+// LoggingUnaryInterceptor записывает в журнал метод, адрес клиента, длительность и код ответа.
+func LoggingUnaryInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	start := time.Now()
+	resp, err := handler(ctx, req)
+	logCall(ctx, info.FullMethod, start, err)
+	return resp, err
+}
+
+// LoggingStreamInterceptor записывает в журнал потоковые вызовы после их завершения.
+func LoggingStreamInterceptor(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	start := time.Now()
+	err := handler(srv, ss)
+	logCall(ss.Context(), info.FullMethod, start, err)
+	return err
+}
+
+func logCall(ctx context.Context, method string, start time.Time, err error) {
+	log.Printf("gRPC %s peer=%s code=%s duration=%s request_id=%s",
+		method, ratelimit.PeerAddress(ctx), status.Code(err), time.Since(start), RequestIDFromContext(ctx))
+}
+
+// contextStream ServerStream с подменённым контекстом.
+type contextStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *contextStream) Context() context.Context {
+	return s.ctx
+}
@@ -0,0 +1,154 @@
+package server
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"go_micro_gRPS/internal/apperrors"
+	"go_micro_gRPS/internal/database"
+	"go_micro_gRPS/internal/models"
+	pb "go_micro_gRPS/proto/go_micro_gRPC/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// defaultPageSize размер страницы ListMessages по умолчанию
+	defaultPageSize = 50
+	// maxPageSize максимальный размер страницы ListMessages
+	maxPageSize = 500
+)
+
+// GetMessage Метод GetMessage возвращает сообщение по его ID.
+func (s *Server) GetMessage(ctx context.Context, req *pb.GetMessageRequest) (*pb.Message, error) {
+	msg, err := database.GetMessage(ctx, s.db, int(req.Id))
+	if errors.Is(err, database.ErrMessageNotFound) {
+		return nil, apperrors.ToGRPC(apperrors.NotFound(fmt.Sprintf("message %d not found", req.Id)))
+	}
+	if err != nil {
+		log.Printf("Error getting message from database: %v", err)
+		return nil, apperrors.ToGRPC(err)
+	}
+
+	return toProtoMessage(*msg), nil
+}
+
+// ListMessages Метод ListMessages возвращает страницу сообщений, отфильтрованных по статусу, топику, приоритету и времени создания.
+func (s *Server) ListMessages(ctx context.Context, req *pb.ListMessagesRequest) (*pb.ListMessagesResponse, error) {
+	pageSize := int(req.PageSize)
+	if pageSize <= 0 {
+		pageSize = defaultPageSize
+	}
+	if pageSize > maxPageSize {
+		pageSize = maxPageSize
+	}
+
+	beforeID, err := decodePageToken(req.PageToken)
+	if err != nil {
+		return nil, apperrors.ToGRPC(apperrors.InvalidArgument("page_token", "invalid page token"))
+	}
+
+	createdAfter, createdBefore, err := createdRange(req)
+	if err != nil {
+		return nil, apperrors.ToGRPC(err)
+	}
+
+	filter := database.MessageFilter{
+		Statuses:      req.Statuses,
+		Topics:        req.Topics,
+		Priorities:    req.Priorities,
+		CreatedAfter:  createdAfter,
+		CreatedBefore: createdBefore,
+		BeforeID:      beforeID,
+		Limit:         pageSize + 1, // Лишнее сообщение показывает, что есть следующая страница
+	}
+
+	messages, err := database.ListMessages(ctx, s.db, filter)
+	if err != nil {
+		log.Printf("Error listing messages from database: %v", err)
+		return nil, apperrors.ToGRPC(err)
+	}
+
+	res := &pb.ListMessagesResponse{}
+	if len(messages) > pageSize {
+		messages = messages[:pageSize]
+		res.NextPageToken = encodePageToken(messages[len(messages)-1].ID)
+	}
+	for _, msg := range messages {
+		res.Messages = append(res.Messages, toProtoMessage(msg))
+	}
+	return res, nil
+}
+
+// createdRange проверяет границы времени создания в запросе ListMessages; отсутствующая граница
+// возвращается нулевым временем и не ограничивает выборку.
+func createdRange(req *pb.ListMessagesRequest) (after, before time.Time, err error) {
+	if req.CreatedAfter != nil {
+		if err := req.CreatedAfter.CheckValid(); err != nil {
+			return after, before, apperrors.InvalidArgument("created_after", "invalid timestamp")
+		}
+		after = req.CreatedAfter.AsTime()
+	}
+	if req.CreatedBefore != nil {
+		if err := req.CreatedBefore.CheckValid(); err != nil {
+			return after, before, apperrors.InvalidArgument("created_before", "invalid timestamp")
+		}
+		before = req.CreatedBefore.AsTime()
+	}
+	if !after.IsZero() && !before.IsZero() && after.After(before) {
+		return after, before, apperrors.InvalidArgument("created_after", "created_after must not be later than created_before")
+	}
+	return after, before, nil
+}
+
+// toProtoMessage преобразует сообщение из БД в protobuf-представление.
+func toProtoMessage(msg models.Message) *pb.Message {
+	res := &pb.Message{
+		Id:          int32(msg.ID),
+		Content:     msg.Content,
+		Status:      msg.Status,
+		CreatedAt:   timestamppb.New(msg.CreatedAt),
+		CreatedBy:   msg.CreatedBy,
+		Key:         msg.Key,
+		Attributes:  msg.Attributes,
+		ContentType: msg.ContentType,
+		Topic:       msg.Topic,
+		Priority:    msg.Priority,
+	}
+	if !msg.DeliverAt.IsZero() {
+		res.DeliverAt = timestamppb.New(msg.DeliverAt)
+	}
+	if !msg.ExpiresAt.IsZero() {
+		res.ExpiresAt = timestamppb.New(msg.ExpiresAt)
+	}
+	return res
+}
+
+// encodePageToken кодирует курсор (ID последнего сообщения страницы) в непрозрачный токен.
+func encodePageToken(lastID int) string {
+	return base64.RawURLEncoding.EncodeToString([]byte("id:" + strconv.Itoa(lastID)))
+}
+
+// decodePageToken извлекает курсор из токена страницы; пустой токен означает первую страницу.
+func decodePageToken(token string) (int, error) {
+	if token == "" {
+		return 0, nil
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return 0, err
+	}
+	idStr, ok := strings.CutPrefix(string(raw), "id:")
+	if !ok {
+		return 0, errors.New("malformed page token")
+	}
+	id, err := strconv.Atoi(idStr)
+	if err != nil || id <= 0 {
+		return 0, errors.New("malformed page token")
+	}
+	return id, nil
+}
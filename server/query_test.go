@@ -0,0 +1,56 @@
+package server
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"go_micro_gRPS/internal/apperrors"
+	pb "go_micro_gRPS/proto/go_micro_gRPC/proto"
+
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func TestCreatedRange(t *testing.T) {
+	early := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+	late := early.Add(time.Hour)
+	invalid := &timestamppb.Timestamp{Seconds: 1, Nanos: -1}
+
+	tests := []struct {
+		name          string
+		after, before *timestamppb.Timestamp
+		wantField     string // Поле ошибки InvalidArgument; пусто - диапазон корректен
+	}{
+		{"без границ", nil, nil, ""},
+		{"только нижняя граница", timestamppb.New(early), nil, ""},
+		{"только верхняя граница", nil, timestamppb.New(late), ""},
+		{"корректный диапазон", timestamppb.New(early), timestamppb.New(late), ""},
+		{"совпадающие границы", timestamppb.New(early), timestamppb.New(early), ""},
+		{"начало эпохи", timestamppb.New(time.Unix(0, 0)), timestamppb.New(late), ""},
+		{"нижняя граница позже верхней", timestamppb.New(late), timestamppb.New(early), "created_after"},
+		{"некорректная нижняя граница", invalid, nil, "created_after"},
+		{"некорректная верхняя граница", nil, invalid, "created_before"},
+		{"нижняя граница вне диапазона", &timestamppb.Timestamp{Seconds: 1 << 40}, nil, "created_after"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			after, before, err := createdRange(&pb.ListMessagesRequest{CreatedAfter: tt.after, CreatedBefore: tt.before})
+			if tt.wantField == "" {
+				if err != nil {
+					t.Fatalf("ошибка %v", err)
+				}
+				if (tt.after != nil && !after.Equal(tt.after.AsTime())) || (tt.before != nil && !before.Equal(tt.before.AsTime())) {
+					t.Errorf("диапазон [%v, %v]", after, before)
+				}
+				if (tt.after == nil) != after.IsZero() || (tt.before == nil) != before.IsZero() {
+					t.Errorf("отсутствующая граница должна быть нулевым временем: [%v, %v]", after, before)
+				}
+				return
+			}
+			var appErr *apperrors.Error
+			if !errors.As(err, &appErr) || appErr.Kind != apperrors.KindInvalidArgument || appErr.Field != tt.wantField {
+				t.Errorf("ошибка %v, ожидался InvalidArgument по полю %s", err, tt.wantField)
+			}
+		})
+	}
+}
@@ -0,0 +1,49 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go_micro_gRPS/internal/apperrors"
+	"go_micro_gRPS/internal/database"
+	pb "go_micro_gRPS/proto/go_micro_gRPC/proto"
+	"log"
+	"time"
+)
+
+// RetryMessage Метод RetryMessage повторно отправляет в Kafka сообщение со статусом failed.
+// Сообщения в других статусах и с истекшим сроком жизни не отправляются (FailedPrecondition).
+func (s *Server) RetryMessage(ctx context.Context, req *pb.RetryMessageRequest) (*pb.Message, error) {
+	msg, err := database.ClaimFailedMessage(ctx, s.db, int(req.Id))
+	switch {
+	case errors.Is(err, database.ErrMessageNotFound):
+		return nil, apperrors.ToGRPC(apperrors.NotFound(fmt.Sprintf("message %d not found", req.Id)))
+	case errors.Is(err, database.ErrMessageNotRetryable):
+		return nil, apperrors.ToGRPC(s.notRetryable(ctx, int(req.Id)))
+	case err != nil:
+		log.Printf("Error claiming message for retry: %v", err)
+		return nil, apperrors.ToGRPC(err)
+	}
+
+	if err := s.kafkaProducer.SendMessage(ctx, kafkaMessage(msg.ID, *msg)); err != nil {
+		log.Printf("Error resending message %d to Kafka: %v", msg.ID, err)
+		s.updateStatuses(ctx, []int{msg.ID}, "failed")
+		return nil, apperrors.ToGRPC(err)
+	}
+	s.updateStatuses(ctx, []int{msg.ID}, "processed")
+
+	msg.Status = "processed"
+	return toProtoMessage(*msg), nil
+}
+
+// notRetryable объясняет, почему сообщение нельзя отправить повторно.
+func (s *Server) notRetryable(ctx context.Context, id int) error {
+	msg, err := database.GetMessage(ctx, s.db, id)
+	if err != nil {
+		return apperrors.FailedPrecondition(fmt.Sprintf("message %d cannot be retried", id))
+	}
+	if msg.Status == "failed" && msg.Expired(time.Now()) {
+		return apperrors.FailedPrecondition(fmt.Sprintf("message %d has expired at %s", id, msg.ExpiresAt.UTC().Format(time.RFC3339)))
+	}
+	return apperrors.FailedPrecondition(fmt.Sprintf("message %d has status %s, only failed messages can be retried", id, msg.Status))
+}
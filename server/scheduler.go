@@ -0,0 +1,101 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"go_micro_gRPS/internal/database"
+	"go_micro_gRPS/internal/kafka_services"
+	"go_micro_gRPS/internal/models"
+	"log"
+	"time"
+)
+
+const (
+	// schedulerSendTimeout максимальное время отправки одного пакета отложенных сообщений
+	schedulerSendTimeout = 30 * time.Second
+	// defaultSchedulerInterval период опроса БД по умолчанию
+	defaultSchedulerInterval = time.Second
+	// defaultSchedulerBatchSize размер пакета отложенных сообщений по умолчанию
+	defaultSchedulerBatchSize = 100
+)
+
+// Scheduler отправляет в Kafka отложенные сообщения, время доставки которых наступило,
+// и переводит в статус 'expired' необработанные сообщения с истекшим сроком жизни.
+// Несколько экземпляров сервиса могут работать одновременно: выбранные строки блокируются
+// в БД до окончания отправки (см. database.DeliverDueMessages).
+type Scheduler struct {
+	db        *sql.DB
+	producer  *kafka_services.Producer
+	interval  time.Duration
+	batchSize int
+}
+
+// NewScheduler создаёт планировщик, опрашивающий БД с периодом interval и отправляющий
+// не более batchSize сообщений за один запрос. Неположительные значения заменяются значениями по умолчанию.
+func NewScheduler(db *sql.DB, producer *kafka_services.Producer, interval time.Duration, batchSize int) *Scheduler {
+	if interval <= 0 {
+		interval = defaultSchedulerInterval
+	}
+	if batchSize < 1 {
+		batchSize = defaultSchedulerBatchSize
+	}
+	return &Scheduler{db: db, producer: producer, interval: interval, batchSize: batchSize}
+}
+
+// Run опрашивает БД до отмены контекста.
+func (s *Scheduler) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.expire(ctx)
+			s.deliverDue(ctx)
+		}
+	}
+}
+
+// expire переводит сообщения с истекшим сроком жизни в статус 'expired'.
+func (s *Scheduler) expire(ctx context.Context) {
+	expired, err := database.ExpireMessages(ctx, s.db)
+	if err != nil {
+		log.Printf("Error expiring messages: %v", err)
+		return
+	}
+	if expired > 0 {
+		log.Printf("Expired %d messages", expired)
+	}
+}
+
+// deliverDue отправляет все наступившие сообщения пакетами по batchSize.
+func (s *Scheduler) deliverDue(ctx context.Context) {
+	for ctx.Err() == nil {
+		n, err := s.deliverBatch(ctx)
+		if err != nil {
+			log.Printf("Error delivering scheduled messages: %v", err)
+			return
+		}
+		if n > 0 {
+			log.Printf("Processed %d scheduled messages", n)
+		}
+		if n < s.batchSize {
+			return
+		}
+	}
+}
+
+func (s *Scheduler) deliverBatch(ctx context.Context) (int, error) {
+	ctx, cancel := context.WithTimeout(ctx, schedulerSendTimeout)
+	defer cancel()
+
+	return database.DeliverDueMessages(ctx, s.db, s.batchSize, func(messages []models.Message) []error {
+		records := make([]kafka_services.KeyedMessage, len(messages))
+		for i, msg := range messages {
+			records[i] = kafkaMessage(msg.ID, msg)
+		}
+		return kafka_services.MessageErrors(s.producer.SendMessages(ctx, records), len(records))
+	})
+}
@@ -0,0 +1,156 @@
+package server
+
+import (
+	"context"
+	"go_micro_gRPS/internal/apperrors"
+	"go_micro_gRPS/internal/auth"
+	"go_micro_gRPS/internal/database"
+	"go_micro_gRPS/internal/kafka_services"
+	pb "go_micro_gRPS/proto/go_micro_gRPC/proto"
+	"io"
+	"log"
+	"time"
+)
+
+const (
+	// streamBatchSize максимальное количество сообщений, отправляемых в Kafka одним пакетом
+	streamBatchSize = 500
+	// streamFlushInterval максимальное время ожидания заполнения пакета
+	streamFlushInterval = 50 * time.Millisecond
+)
+
+// SendMessageStream Метод SendMessageStream принимает поток сообщений, сохраняет их в БД
+// и отправляет в Kafka пакетами, возвращая ack на каждое сообщение.
+func (s *Server) SendMessageStream(stream pb.MessageService_SendMessageStreamServer) error {
+	ctx := stream.Context()
+
+	// Чтение входящих сообщений в отдельной горутине, чтобы пакет можно было отправить по таймеру
+	requests := make(chan *pb.StreamMessageRequest, streamBatchSize)
+	recvErr := make(chan error, 1)
+	go func() {
+		defer close(requests)
+		for {
+			req, err := stream.Recv()
+			if err != nil {
+				if err != io.EOF {
+					recvErr <- err
+				}
+				return
+			}
+			select {
+			case requests <- req:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	batch := make([]*pb.StreamMessageRequest, 0, streamBatchSize)
+	ticker := time.NewTicker(streamFlushInterval)
+	defer ticker.Stop()
+
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		err := s.flushStreamBatch(ctx, stream, batch)
+		batch = batch[:0]
+		return err
+	}
+
+	for {
+		select {
+		case req, ok := <-requests:
+			if !ok {
+				// Клиент закончил отправку: подтверждаем оставшиеся сообщения
+				if err := flush(); err != nil {
+					return err
+				}
+				select {
+				case err := <-recvErr:
+					log.Printf("Error receiving message from stream: %v", err)
+					return apperrors.ToGRPC(err)
+				default:
+					return nil
+				}
+			}
+			batch = append(batch, req)
+			if len(batch) >= streamBatchSize {
+				if err := flush(); err != nil {
+					return err
+				}
+			}
+		case <-ticker.C:
+			if err := flush(); err != nil {
+				return err
+			}
+		case <-ctx.Done():
+			return apperrors.ToGRPC(ctx.Err())
+		}
+	}
+}
+
+// flushStreamBatch сохраняет пакет сообщений в БД, отправляет его в Kafka одним вызовом и отвечает ack'ами.
+func (s *Server) flushStreamBatch(ctx context.Context, stream pb.MessageService_SendMessageStreamServer, batch []*pb.StreamMessageRequest) error {
+	acks := make([]*pb.MessageAck, len(batch))
+	sent := make([]int, 0, len(batch)) // Индексы сообщений пакета, сохраненных в БД
+	messages := make([]kafka_services.KeyedMessage, 0, len(batch))
+
+	createdBy := auth.SubjectFromContext(ctx)
+	for i, req := range batch {
+		acks[i] = &pb.MessageAck{CorrelationId: req.CorrelationId}
+
+		// Сообщение формируется и проверяется так же, как в SendMessage и BatchSendMessages
+		msg, err := s.messageFromRequest(&pb.MessageRequest{
+			Content:     req.Content,
+			Key:         req.Key,
+			Attributes:  req.Attributes,
+			ContentType: req.ContentType,
+			Topic:       req.Topic,
+			Priority:    req.Priority,
+		}, createdBy)
+		if err != nil {
+			acks[i].Status = "failed"
+			acks[i].Error = err.Error()
+			continue
+		}
+
+		// Сохранение сообщения в базе данных
+		id, err := database.SaveMessage(ctx, s.db, msg)
+		if err != nil {
+			log.Printf("Error saving message to database: %v", err)
+			acks[i].Status = "failed"
+			acks[i].Error = "failed to save message"
+			continue
+		}
+
+		acks[i].Id = int32(id)
+		sent = append(sent, i)
+		messages = append(messages, kafkaMessage(id, msg))
+	}
+
+	// Отправка сохраненных сообщений в Kafka одним пакетом
+	errs := kafka_services.MessageErrors(s.kafkaProducer.SendMessages(ctx, messages), len(messages))
+	var processed, failed []int
+	for j, i := range sent {
+		if errs[j] != nil {
+			log.Printf("Error sending message %d to Kafka: %v", acks[i].Id, errs[j])
+			failed = append(failed, int(acks[i].Id))
+			acks[i].Status = "failed"
+			acks[i].Error = "failed to send message to Kafka"
+			continue
+		}
+		processed = append(processed, int(acks[i].Id))
+		acks[i].Status = "sent"
+	}
+	s.updateStatuses(ctx, processed, "processed")
+	s.updateStatuses(ctx, failed, "failed")
+
+	for _, ack := range acks {
+		if err := stream.Send(ack); err != nil {
+			log.Printf("Error sending ack: %v", err)
+			return err
+		}
+	}
+	return nil
+}
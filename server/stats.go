@@ -0,0 +1,68 @@
+package server
+
+import (
+	"context"
+	"go_micro_gRPS/internal/apperrors"
+	"go_micro_gRPS/internal/database"
+	pb "go_micro_gRPS/proto/go_micro_gRPC/proto"
+	"log"
+	"strings"
+	"time"
+
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// GetStats Метод GetStats возвращает количество сообщений по статусам и приоритетам, скорость приема и обработки
+// в скользящих окнах и перцентили задержки от создания до обработки, по всем топикам или по одному.
+func (s *Server) GetStats(ctx context.Context, req *pb.StatsRequest) (*pb.Stats, error) {
+	stats, err := database.GetStats(ctx, s.db, req.Topic)
+	if err != nil {
+		log.Printf("Error getting message stats: %v", err)
+		return nil, apperrors.ToGRPC(err)
+	}
+
+	res := &pb.Stats{
+		StatusCounts:   stats.StatusCounts,
+		PriorityCounts: stats.PriorityCounts,
+		GeneratedAt:    timestamppb.Now(),
+		Latency: &pb.LatencyStats{
+			Window: formatWindow(database.LatencyWindow),
+			Count:  stats.Latency.Count,
+			AvgMs:  milliseconds(stats.Latency.Avg),
+			P50Ms:  milliseconds(stats.Latency.P50),
+			P90Ms:  milliseconds(stats.Latency.P90),
+			P95Ms:  milliseconds(stats.Latency.P95),
+			P99Ms:  milliseconds(stats.Latency.P99),
+			MaxMs:  milliseconds(stats.Latency.Max),
+		},
+	}
+	for _, count := range stats.StatusCounts {
+		res.TotalCount += count
+	}
+	for _, t := range stats.Throughput {
+		res.Throughput = append(res.Throughput, &pb.ThroughputWindow{
+			Window:         formatWindow(t.Window),
+			Ingested:       t.Ingested,
+			Processed:      t.Processed,
+			IngestRate:     float64(t.Ingested) / t.Window.Seconds(),
+			ProcessingRate: float64(t.Processed) / t.Window.Seconds(),
+		})
+	}
+	return res, nil
+}
+
+// formatWindow форматирует длительность окна кратко: "1m", "5m", "1h".
+func formatWindow(d time.Duration) string {
+	s := d.String()
+	if strings.HasSuffix(s, "m0s") {
+		s = s[:len(s)-2]
+	}
+	if strings.HasSuffix(s, "h0m") {
+		s = s[:len(s)-2]
+	}
+	return s
+}
+
+func milliseconds(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}